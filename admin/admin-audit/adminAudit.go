@@ -0,0 +1,61 @@
+package adminaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/asuc-octo/octoapi/platform"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// AuditLogEndpoint returns the newest entries of the admin audit log. Pass
+// uid to see only the changes made to one user and limit to bound the count.
+func AuditLogEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/admin/audit", auditLogEndpoint, http.MethodGet)
+}
+
+func auditLogEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !platform.Authenticate(w, r, platform.ScopeAdminRead) {
+		return
+	}
+	limit := defaultLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxLimit {
+			platform.InvalidParam(w, "limit")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	entries, err := db.ListAuditLog(ctx, r.URL.Query().Get("uid"), limit)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("audit log GET failed: %v", err)
+		return
+	}
+	output, err := json.Marshal(entries)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("audit log JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
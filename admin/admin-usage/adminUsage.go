@@ -0,0 +1,91 @@
+package adminusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/usage"
+)
+
+// Usage is every user's usage over a time range, grouped as requested.
+type Usage struct {
+	From   int64         `json:"from"`
+	To     int64         `json:"to"`
+	Counts []usage.Count `json:"counts"`
+}
+
+// AdminUsageEndpoint reports request counts between the from and to query
+// params, in Unix seconds, by default the last 30 days. group_by is
+// "endpoint" (the default), "uid" or "endpoint,uid"; uid restricts the
+// report to one user.
+func AdminUsageEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/admin/usage", adminUsageEndpoint, http.MethodGet)
+}
+
+func adminUsageEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !platform.Authenticate(w, r, platform.ScopeAdminRead) {
+		return
+	}
+	q, ok := platform.UsageQuery(w, r)
+	if !ok {
+		return
+	}
+	q.UID = r.URL.Query().Get("uid")
+	byEndpoint, byUser := false, false
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = usage.ByEndpoint
+	}
+	for _, group := range strings.Split(groupBy, ",") {
+		switch group {
+		case usage.ByEndpoint:
+			byEndpoint = true
+		case usage.ByUser:
+			byUser = true
+		default:
+			platform.InvalidParam(w, "group_by")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	sink, err := platform.UsageSink(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("usage sink init failed: %v", err)
+		return
+	}
+	var records []usage.Record
+	if sink != nil {
+		records, err = sink.Records(ctx, q)
+	}
+	if sink == nil || err == usage.ErrNotQueryable {
+		platform.WriteError(w, http.StatusNotImplemented, platform.Error{
+			Code:    platform.CodeInvalidRequest,
+			Message: "Usage reports aren't available on this deployment.",
+		})
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("usage GET failed: %v", err)
+		return
+	}
+	output, err := json.Marshal(Usage{
+		From:   q.From,
+		To:     q.To,
+		Counts: usage.Summarize(records, byEndpoint, byUser),
+	})
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("usage JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
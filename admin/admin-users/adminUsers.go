@@ -0,0 +1,217 @@
+package adminusers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+const maxReasonLength = 500
+
+// User is an API user as shown to admins.
+type User struct {
+	UID           string `json:"uid"`
+	Admin         bool   `json:"admin"`
+	CreatedAt     int64  `json:"created_at"`
+	LastActiveAt  *int64 `json:"last_active_at"`
+	Blocked       bool   `json:"blocked"`
+	BlockedAt     *int64 `json:"blocked_at"`
+	BlockedReason string `json:"blocked_reason,omitempty"`
+}
+
+type blockRequest struct {
+	UID    string `json:"uid"`
+	Reason string `json:"reason"`
+}
+
+// ListUsersEndpoint lists every API user with their creation, last-activity
+// and block details. Pass blocked=true or blocked=false to filter.
+func ListUsersEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/admin/users", listUsersEndpoint, http.MethodGet)
+}
+
+func listUsersEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !platform.Authenticate(w, r, platform.ScopeAdminRead) {
+		return
+	}
+	blockedFilter := r.URL.Query().Get("blocked")
+	if blockedFilter != "" && blockedFilter != "true" && blockedFilter != "false" {
+		platform.InvalidParam(w, "blocked")
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	list, err := db.List(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users GET failed: %v", err)
+		return
+	}
+	views := make([]User, 0, len(list))
+	for _, user := range list {
+		if blockedFilter != "" && fmt.Sprint(user.Blocked) != blockedFilter {
+			continue
+		}
+		views = append(views, view(user))
+	}
+	writeJSON(w, views)
+}
+
+// BlockUserEndpoint blocks a user, given a body like {"uid": "...",
+// "reason": "..."}. Their refresh tokens and API keys stop working at once and
+// their access tokens within a minute.
+func BlockUserEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/admin/users/block", blockUserEndpoint, http.MethodPost)
+}
+
+func blockUserEndpoint(w http.ResponseWriter, r *http.Request) {
+	setBlocked(w, r, true)
+}
+
+// UnblockUserEndpoint lifts a block, given a body like {"uid": "...",
+// "reason": "..."}.
+func UnblockUserEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/admin/users/unblock", unblockUserEndpoint, http.MethodPost)
+}
+
+func unblockUserEndpoint(w http.ResponseWriter, r *http.Request) {
+	setBlocked(w, r, false)
+}
+
+func setBlocked(w http.ResponseWriter, r *http.Request, blocked bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateClaims(w, r, platform.ScopeAdminWrite)
+	if !ok {
+		return
+	}
+	var body blockRequest
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(reqBody, &body)
+	}
+	if err != nil {
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidBody,
+			Message: "Invalid body params",
+		})
+		return
+	}
+	body.Reason = strings.TrimSpace(body.Reason)
+	if body.UID == "" {
+		platform.MissingBodyParam(w, "uid")
+		return
+	}
+	if body.Reason == "" {
+		platform.MissingBodyParam(w, "reason")
+		return
+	}
+	if len(body.Reason) > maxReasonLength {
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidParam,
+			Param:   "reason",
+			Message: fmt.Sprintf("Body param 'reason' must be at most %d characters", maxReasonLength),
+		})
+		return
+	}
+	if body.UID == claims.UID {
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidParam,
+			Param:   "uid",
+			Message: "You can't block or unblock yourself",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	now := time.Now().Unix()
+	action := users.ActionUnblock
+	if blocked {
+		action = users.ActionBlock
+	}
+	var updated users.User
+	err = db.UpdateAudited(ctx, body.UID, func(user *users.User) error {
+		user.Blocked = blocked
+		if blocked {
+			user.BlockedAt = now
+			user.BlockedReason = body.Reason
+			user.RevokeRefreshTokens()
+		} else {
+			user.BlockedAt = 0
+			user.BlockedReason = ""
+		}
+		updated = *user
+		return nil
+	}, &users.AuditEntry{
+		Actor:  claims.UID,
+		Action: action,
+		Target: body.UID,
+		Reason: body.Reason,
+		At:     now,
+	})
+	if err == users.ErrNotFound {
+		platform.WriteError(w, http.StatusNotFound, platform.Error{
+			Code:    platform.CodeNotFound,
+			Param:   "uid",
+			Message: "No user with uid '" + body.UID + "'",
+		})
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users update failed: %v", err)
+		return
+	}
+	platform.RememberBlocked(body.UID, blocked)
+	writeJSON(w, view(updated))
+}
+
+func view(user users.User) User {
+	out := User{
+		UID:           user.UID,
+		Admin:         user.Admin,
+		CreatedAt:     user.CreatedAt,
+		Blocked:       user.Blocked,
+		BlockedReason: user.BlockedReason,
+	}
+	if user.LastActiveAt != 0 {
+		out.LastActiveAt = &user.LastActiveAt
+	}
+	if user.BlockedAt != 0 {
+		out.BlockedAt = &user.BlockedAt
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	output, err := json.Marshal(v)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
@@ -0,0 +1,314 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+const maxNameLength = 100
+
+// APIKey is an API key as shown to its owner. Key is only set in the
+// response that creates it.
+type APIKey struct {
+	ID         string   `json:"id"`
+	Key        string   `json:"key,omitempty"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"created_at"`
+	LastUsedAt *int64   `json:"last_used_at"`
+	RevokedAt  *int64   `json:"revoked_at"`
+}
+
+type createRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type keyRequest struct {
+	ID string `json:"id"`
+}
+
+// APIKeysEndpoint lists the caller's API keys on GET and creates one on POST
+// from a body like {"name": "dining bot", "scopes": ["dining:read"]}. Keys
+// can only be managed with an access token, not with another API key.
+func APIKeysEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/auth/keys", apiKeysEndpoint, http.MethodGet, http.MethodPost)
+}
+
+func apiKeysEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateUser(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		listKeys(w, r, claims)
+	case http.MethodPost:
+		createKey(w, r, claims)
+	}
+}
+
+// RevokeAPIKeyEndpoint revokes one of the caller's API keys, given a body
+// like {"id": "<key id>"}. Revoked keys stay listed.
+func RevokeAPIKeyEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/auth/keys/revoke", revokeAPIKeyEndpoint, http.MethodPost)
+}
+
+func revokeAPIKeyEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateUser(w, r)
+	if !ok {
+		return
+	}
+	var body keyRequest
+	if !decodeKeyRequest(w, r, &body) {
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	revoked, ok := revokeKey(w, r, db, claims, body.ID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, View(*revoked, ""))
+}
+
+// RegenerateAPIKeyEndpoint replaces one of the caller's API keys, given a body
+// like {"id": "<key id>"}, with a new key of the same name and scopes, and
+// revokes the old one. The new key is only shown in this response.
+func RegenerateAPIKeyEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/auth/keys/regenerate", regenerateAPIKeyEndpoint, http.MethodPost)
+}
+
+func regenerateAPIKeyEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateUser(w, r)
+	if !ok {
+		return
+	}
+	var body keyRequest
+	if !decodeKeyRequest(w, r, &body) {
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	user, err := db.Get(ctx, claims.UID)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users GET failed: %v", err)
+		return
+	}
+	// The new key can't grant more than the token regenerating it.
+	allowed := platform.IntersectScopes(platform.AllowedScopes(user), platform.ParseScope(claims.Scope))
+	// The old key is revoked and the new one created together, so a failure
+	// leaves the old key working and concurrent calls can't both replace it.
+	var secret string
+	var key *users.APIKey
+	err = db.ReplaceAPIKey(ctx, body.ID, func(old *users.APIKey) (*users.APIKey, error) {
+		if old.UID != claims.UID || old.Revoked() {
+			return nil, users.ErrNotFound
+		}
+		old.RevokedAt = time.Now().Unix()
+		var err error
+		secret, key, err = platform.GenerateAPIKey(claims.UID, old.Name, platform.IntersectScopes(old.Scopes, allowed))
+		return key, err
+	})
+	if err == users.ErrNotFound {
+		keyNotFound(w, body.ID)
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("API key regeneration failed: %v", err)
+		return
+	}
+	platform.NotifyCredentialIssued(ctx, user, "API key", key.Name)
+	writeJSON(w, http.StatusCreated, View(*key, secret))
+}
+
+// revokeKey revokes the caller's key with the given id and returns it,
+// replying with an error when that fails.
+func revokeKey(w http.ResponseWriter, r *http.Request, db users.Store, claims *platform.Claims, id string) (*users.APIKey, bool) {
+	var revoked users.APIKey
+	err := db.UpdateAPIKey(r.Context(), id, func(key *users.APIKey) error {
+		if key.UID != claims.UID {
+			return users.ErrNotFound
+		}
+		if !key.Revoked() {
+			key.RevokedAt = time.Now().Unix()
+		}
+		revoked = *key
+		return nil
+	})
+	if err == users.ErrNotFound {
+		keyNotFound(w, id)
+		return nil, false
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("API key revocation failed: %v", err)
+		return nil, false
+	}
+	return &revoked, true
+}
+
+func keyNotFound(w http.ResponseWriter, id string) {
+	platform.WriteError(w, http.StatusNotFound, platform.Error{
+		Code:    platform.CodeNotFound,
+		Param:   "id",
+		Message: "No API key with id '" + id + "'",
+	})
+}
+
+func listKeys(w http.ResponseWriter, r *http.Request, claims *platform.Claims) {
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	keys, err := db.ListAPIKeys(ctx, claims.UID)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("API keys GET failed: %v", err)
+		return
+	}
+	views := make([]APIKey, 0, len(keys))
+	for _, key := range keys {
+		views = append(views, View(key, ""))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func createKey(w http.ResponseWriter, r *http.Request, claims *platform.Claims) {
+	var body createRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > maxNameLength {
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidParam,
+			Param:   "name",
+			Message: fmt.Sprintf("Body param 'name' must be between 1 and %d characters", maxNameLength),
+		})
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	user, err := db.Get(ctx, claims.UID)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users GET failed: %v", err)
+		return
+	}
+	// A key can't grant more than the token creating it.
+	allowed := platform.IntersectScopes(platform.AllowedScopes(user), platform.ParseScope(claims.Scope))
+	scopes, denied := platform.GrantScopes(body.Scopes, allowed)
+	if denied != "" {
+		platform.InvalidScope(w, denied)
+		return
+	}
+	secret, key, err := platform.NewAPIKey(ctx, db, claims.UID, body.Name, scopes)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("API key creation failed: %v", err)
+		return
+	}
+	platform.NotifyCredentialIssued(ctx, user, "API key", key.Name)
+	writeJSON(w, http.StatusCreated, View(*key, secret))
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(reqBody, v)
+	}
+	if err != nil {
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidBody,
+			Message: "Invalid body params",
+		})
+		return false
+	}
+	return true
+}
+
+func decodeKeyRequest(w http.ResponseWriter, r *http.Request, body *keyRequest) bool {
+	if !decodeBody(w, r, body) {
+		return false
+	}
+	if body.ID == "" {
+		platform.MissingBodyParam(w, "id")
+		return false
+	}
+	return true
+}
+
+// View returns key as shown to its owner, with the full key when it was just
+// issued.
+func View(key users.APIKey, secret string) APIKey {
+	out := APIKey{
+		ID:        key.ID,
+		Key:       secret,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+	}
+	if out.Scopes == nil {
+		out.Scopes = []string{}
+	}
+	if key.LastUsedAt != 0 {
+		out.LastUsedAt = &key.LastUsedAt
+	}
+	if key.RevokedAt != 0 {
+		out.RevokedAt = &key.RevokedAt
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	output, err := json.Marshal(v)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("API keys JSON conversion failed: %v", err)
+		return
+	}
+	w.WriteHeader(status)
+	fmt.Fprint(w, string(output))
+}
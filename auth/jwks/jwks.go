@@ -0,0 +1,35 @@
+package jwks
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/asuc-octo/octoapi/platform"
+)
+
+// JWKSEndpoint publishes the public keys tokens are signed with so clients
+// can verify them without holding a secret. Tokens name their key in kid.
+func JWKSEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/.well-known/jwks.json", jwksEndpoint, http.MethodGet)
+}
+
+func jwksEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	keys, err := platform.PublicKeys(r.Context())
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("public keys load failed: %v", err)
+		return
+	}
+	output, err := json.Marshal(keys)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("jwks JSON conversion failed: %v", err)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=600")
+	fmt.Fprint(w, string(output))
+}
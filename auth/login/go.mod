@@ -1,10 +0,0 @@
-module example.com/cloudfunction
-
-go 1.15
-
-require (
-	cloud.google.com/go/firestore v1.3.0
-	cloud.google.com/go/storage v1.12.0
-	github.com/dgrijalva/jwt-go v3.2.0+incompatible
-	google.golang.org/api v0.35.0
-)
@@ -3,209 +3,150 @@ package login
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"strings"
 	"time"
 
-	// "github.com/auth0/go-jwt-middleware"
-	firebase "firebase.google.com/go"
-	"github.com/dgrijalva/jwt-go"
-
-	"cloud.google.com/go/firestore"
-
-	sendgrid "github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/users"
 )
 
-type User struct {
-	Uid          string `json:"uid"`
-	Refreshtoken string `json:"refresh-token"`
-	Created_at   int    `json:"created_at"`
-	Blocked      bool   `json:"blocked"`
-	blocked_at   int    `json:"created_at"`
-}
-
 type Tokens struct {
 	RefreshToken string `json:"refresh-token"`
 	AccessToken  string `json:"access-token"`
 }
 
+// AuthEndpoint exchanges a Firebase ID token, sent as {"id-token": "<token>"},
+// for an access token and a refresh token. The token must belong to a
+// verified berkeley.edu email address. An optional space-delimited "scope"
+// limits the scopes granted.
 func AuthEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
+	platform.Serve(w, r, "/v1/auth/login", authEndpoint, http.MethodPost)
+}
+
+func authEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
 	reqBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Invalid body params", http.StatusBadRequest)
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidBody,
+			Message: "Invalid body params",
+		})
 		return
 	}
 	var data map[string]interface{}
 	jsonErr := json.Unmarshal([]byte(reqBody), &data)
 	if jsonErr != nil {
-		http.Error(w, jsonErr.Error(), http.StatusBadRequest)
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidBody,
+			Message: jsonErr.Error(),
+		})
 		return
 	}
-	uid, convertuid := data["uid"].(string)
-	if !convertuid {
-		http.Error(w, "Invalid uid params", http.StatusBadRequest)
+	idToken, convertidtoken := data["id-token"].(string)
+	if !convertidtoken || idToken == "" {
+		platform.MissingBodyParam(w, "id-token")
 		return
 	}
-	client, ctx, clientErr := initFirestore(w)
-
-	if clientErr != nil {
-		http.Error(w, clientErr.Error(), http.StatusInternalServerError)
-		log.Printf("firestore init failed: %v", clientErr)
+	scope, convertscope := data["scope"].(string)
+	if !convertscope && data["scope"] != nil {
+		platform.InvalidParam(w, "scope")
 		return
 	}
-
-	defaultApp, err := firebase.NewApp(context.Background(), nil)
+	ctx := r.Context()
+	account, err := platform.VerifyIDToken(ctx, idToken)
 	if err != nil {
-		http.Error(w, "Error while initializing the app.", http.StatusBadRequest)
+		platform.IDTokenError(w, err)
 		return
 	}
-	defaultClient, err := defaultApp.Auth(context.Background())
-	if err != nil {
-		http.Error(w, "Error while setting the default app", http.StatusBadRequest)
+	uid, email := account.UID, account.Email
+
+	db, dbErr := platform.OpenUsers(ctx)
+	if dbErr != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", dbErr)
 		return
 	}
+	defer db.Close()
 
-	user, err := defaultClient.GetUser(ctx, uid)
-	if err != nil {
-		http.Error(w, "Error while verifying the id", http.StatusBadRequest)
+	refreshToken, accessToken, newDevice, tokenErr := getTokens(uid, email, platform.DeviceID(r), platform.ParseScope(scope), db, ctx)
+	if tokenErr == platform.ErrAccountBlocked {
+		platform.Forbidden(w)
 		return
 	}
-
-	email := user.UserInfo.Email
-	if !strings.Contains(email, "berkeley.edu") {
-		http.Error(w, "Error while verifying user has berkeley email.", http.StatusBadRequest)
+	if scopeErr, ok := tokenErr.(invalidScopeError); ok {
+		platform.InvalidScope(w, string(scopeErr))
+		return
 	}
-
-	refreshToken, accessToken, tokenErr := getTokens(uid, client, ctx)
 	if tokenErr != nil {
-		http.Error(w, tokenErr.Error(), http.StatusBadRequest)
+		platform.InternalError(w)
 		log.Printf("token generation failed: %v", tokenErr)
 		return
 	}
 	tokens := Tokens{refreshToken, accessToken}
 	tokensJSON, jsonErr := json.Marshal(tokens)
 	if jsonErr != nil {
-		http.Error(w, "Couldn’t get tokens", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("token generation failed: %v", jsonErr)
 		return
 	}
+	if newDevice {
+		platform.NotifyNewDevice(ctx, &users.User{UID: uid, Email: email}, r.UserAgent())
+	}
 	w.Write(tokensJSON)
-	sendEmail(email, string(tokensJSON))
 }
 
-func getTokens(uid string, client *firestore.Client, ctx context.Context) (string, string, error) {
-	defer client.Close()
+// invalidScopeError names a requested scope the user can't be granted.
+type invalidScopeError string
 
-	// first check if user already exists in the database
-	userQuery, queryErr := client.Collection("users").Doc(uid).Get(ctx)
-	if queryErr == nil {
-		var user User
-		if getDataErr := userQuery.DataTo(&user); getDataErr != nil {
-			return "", "", getDataErr
+func (e invalidScopeError) Error() string {
+	return "scope " + string(e) + " can't be granted"
+}
+
+// getTokens starts a new refresh token family for the user granting the
+// requested scopes, or the default ones, creating the user on first login and
+// recording the email address and device they logged in with. This revokes
+// the refresh token from any earlier login. It also reports whether the
+// device is new to a user who has logged in before.
+func getTokens(uid, email, device string, requested []string, db users.Store, ctx context.Context) (string, string, bool, error) {
+	var refreshToken string
+	var scopes []string
+	var newDevice bool
+	startFamily := func(user *users.User) error {
+		granted, denied := platform.GrantScopes(requested, platform.AllowedScopes(user))
+		if denied != "" {
+			return invalidScopeError(denied)
 		}
+		scopes = granted
+		user.Email = email
+		user.LastActiveAt = time.Now().Unix()
+		newDevice = user.RememberDevice(device)
+		var tokenGenErr error
+		refreshToken, tokenGenErr = platform.StartRefreshFamily(ctx, user, scopes)
+		return tokenGenErr
+	}
+	// first check if user already exists in the database
+	updateErr := db.Update(ctx, uid, func(user *users.User) error {
 		if user.Blocked {
-			return "", "", errors.New("user is blocked")
+			return platform.ErrAccountBlocked
 		}
-		accessJwtToken, accessTokenGenErr := getAccessJwtToken(uid)
-		if accessTokenGenErr != nil {
-			return "", "", accessTokenGenErr
+		return startFamily(user)
+	})
+	if updateErr == users.ErrNotFound {
+		user := &users.User{UID: uid, CreatedAt: time.Now().Unix()}
+		updateErr = startFamily(user)
+		if updateErr == nil {
+			updateErr = db.Create(ctx, user)
 		}
-		return user.Refreshtoken, accessJwtToken, nil
 	}
-	newJwtToken, tokenGenErr := getRefreshJwtToken(uid)
-	if tokenGenErr != nil {
-		return "", "", tokenGenErr
+	if updateErr != nil {
+		return "", "", false, updateErr
 	}
-	_, addErr := client.Collection("users").Doc(uid).Set(ctx, map[string]interface{}{
-		"uid":          uid,
-		"created_at":   time.Now().Unix(),
-		"refreshtoken": newJwtToken,
-		"blocked":      false,
-		"blocked_at":   nil,
-	})
-	if addErr != nil {
-		return "", "", addErr
-	}
-	accessJwtToken, accessTokenGenErr := getAccessJwtToken(uid)
+	accessJwtToken, accessTokenGenErr := platform.NewAccessToken(ctx, uid, scopes)
 	if accessTokenGenErr != nil {
-		return "", "", accessTokenGenErr
-	}
-
-	return newJwtToken, accessJwtToken, nil
-}
-
-func getRefreshJwtToken(uid string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"uid":  uid,
-		"type": "refresh",
-	})
-	jwtTokenSecret, err := getJwtSecret()
-	if err != nil {
-		return "", err
-	}
-	tokenString, err := token.SignedString(jwtTokenSecret)
-	return tokenString, err
-}
-
-func getAccessJwtToken(uid string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"uid":  uid,
-		"type": "access",
-		"exp":  time.Now().Add(time.Hour * 72).Unix(),
-	})
-	jwtTokenSecret, err := getJwtSecret()
-	if err != nil {
-		return "", err
-	}
-	tokenString, err := token.SignedString(jwtTokenSecret)
-	return tokenString, err
-}
-
-func initFirestore(w http.ResponseWriter) (*firestore.Client, context.Context, error) {
-	// Use the application default credentials
-	ctx := context.Background()
-	client, clientErr := firestore.NewClient(ctx, "api-team-292919")
-	if clientErr != nil {
-		return nil, nil, clientErr
+		return "", "", false, accessTokenGenErr
 	}
-	return client, ctx, nil
+	return refreshToken, accessJwtToken, newDevice, nil
 }
-
-func sendEmail(email string, tokens string) error {
-	emailSecret, emailSecretGenErr := getEmailSecret()
-	if emailSecretGenErr != nil {
-		return emailSecretGenErr
-	}
-	from := mail.NewEmail("OCTO API", "octo.api@asuc.org")
-	subject := "tokens for OCTO API Authorization"
-	to := mail.NewEmail("", email)
-	plainTextContent := tokens
-	message := mail.NewSingleEmail(from, subject, to, plainTextContent, plainTextContent)
-	emailclient := sendgrid.NewSendClient(emailSecret)
-	_, emailErr := emailclient.Send(message)
-	if emailErr != nil {
-		return emailErr
-	}
-	return nil
-}
-
-
-0x8048446
\ No newline at end of file
@@ -0,0 +1,351 @@
+package oauth
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// FirebaseConfigEnv holds the Firebase web app config, as JSON, that the
+// consent page signs users in with.
+const FirebaseConfigEnv = "OCTOAPI_FIREBASE_CONFIG"
+
+// scopeDescriptions tell users on the consent page what each scope lets the
+// app do.
+var scopeDescriptions = map[string]string{
+	platform.ScopeOpenID:        "Know who you are",
+	platform.ScopeEmail:         "See your email address",
+	platform.ScopeGymsRead:      "Read gym information",
+	platform.ScopeLibrariesRead: "Read library information",
+	platform.ScopeDiningRead:    "Read dining hall information",
+	platform.ScopeResourcesRead: "Read campus resource information",
+	platform.ScopeTransitRead:   "Read transit information",
+	platform.ScopeWeatherRead:   "Read the weather",
+}
+
+// authorizeParams are the request parameters the consent page posts back.
+var authorizeParams = []string{
+	"client_id", "redirect_uri", "response_type", "scope", "state",
+	"code_challenge", "code_challenge_method", "nonce",
+}
+
+// authorizeRequest is a validated authorization request (RFC 6749 §4.1.1 with
+// the PKCE parameters of RFC 7636).
+type authorizeRequest struct {
+	client        *users.Client
+	redirectURI   string
+	state         string
+	scopes        []string
+	codeChallenge string
+	nonce         string
+}
+
+// AuthorizeEndpoint starts the authorization code flow. On GET it shows the
+// consent page, where the user signs in with their Berkeley account; the page
+// posts the decision back here, and an allowed request is redirected to the
+// client with a code for the token endpoint. PKCE with S256 is required.
+func AuthorizeEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/oauth/authorize", authorizeEndpoint, http.MethodGet, http.MethodPost)
+}
+
+func authorizeEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	if err := r.ParseForm(); err != nil {
+		renderError(w, http.StatusBadRequest, "The authorization request is malformed.")
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, "Something went wrong. Please try again later.")
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	req, ok := parseAuthorizeRequest(w, r, db)
+	if !ok {
+		return
+	}
+	if r.Method == http.MethodGet {
+		renderConsent(w, r, req)
+		return
+	}
+
+	if r.PostForm.Get("decision") != "allow" {
+		redirectError(w, r, req, "access_denied", "The user denied the request.")
+		return
+	}
+	account, err := platform.VerifyIDToken(ctx, r.PostForm.Get("id_token"))
+	if err != nil {
+		log.Printf("OAuth sign-in failed: %v", err)
+		renderError(w, http.StatusUnauthorized, "Please sign in with a verified "+platform.AllowedEmailDomain+" account.")
+		return
+	}
+	user, notices, err := signIn(r, db, account, req.client.ID)
+	if err == platform.ErrAccountBlocked {
+		redirectError(w, r, req, "access_denied", "The account is blocked.")
+		return
+	}
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, "Something went wrong. Please try again later.")
+		log.Printf("OAuth sign-in failed: %v", err)
+		return
+	}
+
+	allowed := append(platform.AllowedScopes(user), platform.ScopeOpenID, platform.ScopeEmail)
+	code, err := platform.NewAuthCode(ctx, db, users.AuthCode{
+		ClientID:      req.client.ID,
+		UID:           user.UID,
+		RedirectURI:   req.redirectURI,
+		Scopes:        platform.IntersectScopes(req.scopes, allowed),
+		CodeChallenge: req.codeChallenge,
+		Nonce:         req.nonce,
+	})
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, "Something went wrong. Please try again later.")
+		log.Printf("authorization code creation failed: %v", err)
+		return
+	}
+	if notices.newDevice {
+		platform.NotifyNewDevice(ctx, user, r.UserAgent())
+	}
+	if notices.newGrant {
+		platform.NotifyNewGrant(ctx, user, req.client.Name)
+	}
+	redirect(w, r, req, url.Values{"code": {code}})
+}
+
+// parseAuthorizeRequest validates the request parameters. Until the client
+// and redirect URI check out, errors are shown to the user rather than sent
+// to a redirect URI that may not belong to the client.
+func parseAuthorizeRequest(w http.ResponseWriter, r *http.Request, db users.Store) (*authorizeRequest, bool) {
+	client, err := db.GetClient(r.Context(), r.Form.Get("client_id"))
+	if err != nil && err != users.ErrNotFound {
+		renderError(w, http.StatusInternalServerError, "Something went wrong. Please try again later.")
+		log.Printf("OAuth client GET failed: %v", err)
+		return nil, false
+	}
+	if err == users.ErrNotFound || client.Revoked() {
+		renderError(w, http.StatusBadRequest, "The app that sent you here isn't registered.")
+		return nil, false
+	}
+	redirectURI := r.Form.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !contains(client.RedirectURIs, redirectURI) {
+		renderError(w, http.StatusBadRequest, "The app that sent you here asked to return to an address it didn't register.")
+		return nil, false
+	}
+
+	req := &authorizeRequest{
+		client:        client,
+		redirectURI:   redirectURI,
+		state:         r.Form.Get("state"),
+		codeChallenge: r.Form.Get("code_challenge"),
+		nonce:         r.Form.Get("nonce"),
+	}
+	if r.Form.Get("response_type") != "code" {
+		redirectError(w, r, req, "unsupported_response_type", "response_type must be code.")
+		return nil, false
+	}
+	if req.codeChallenge == "" || r.Form.Get("code_challenge_method") != "S256" {
+		redirectError(w, r, req, "invalid_request", "PKCE is required: send a code_challenge with code_challenge_method S256.")
+		return nil, false
+	}
+	req.scopes = client.Scopes
+	if requested := platform.ParseScope(r.Form.Get("scope")); len(requested) > 0 {
+		var denied string
+		req.scopes, denied = platform.GrantScopes(requested, client.Scopes)
+		if denied != "" {
+			redirectError(w, r, req, "invalid_scope", "Scope "+denied+" is unknown or not registered for this client.")
+			return nil, false
+		}
+	}
+	return req, true
+}
+
+// signInNotices says which notices a sign-in calls for.
+type signInNotices struct {
+	newDevice bool
+	newGrant  bool
+}
+
+// signIn records the user's sign-in to the client, creating them on their
+// first, and returns them with the notices it calls for. Blocked users get
+// ErrAccountBlocked.
+func signIn(r *http.Request, db users.Store, account *platform.FirebaseUser, clientID string) (*users.User, signInNotices, error) {
+	ctx := r.Context()
+	now := time.Now().Unix()
+	device := platform.DeviceID(r)
+	var signedIn users.User
+	var notices signInNotices
+	record := func(user *users.User) {
+		user.Email = account.Email
+		user.LastActiveAt = now
+		notices.newDevice = user.RememberDevice(device)
+		notices.newGrant = user.RememberGrant(clientID)
+	}
+	err := db.Update(ctx, account.UID, func(user *users.User) error {
+		if user.Blocked {
+			return platform.ErrAccountBlocked
+		}
+		record(user)
+		signedIn = *user
+		return nil
+	})
+	if err == users.ErrNotFound {
+		signedIn = users.User{UID: account.UID, CreatedAt: now}
+		record(&signedIn)
+		err = db.Create(ctx, &signedIn)
+	}
+	if err != nil {
+		return nil, signInNotices{}, err
+	}
+	return &signedIn, notices, nil
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, req *authorizeRequest, code, description string) {
+	redirect(w, r, req, url.Values{"error": {code}, "error_description": {description}})
+}
+
+// redirect sends the user back to the client with params and the state it
+// passed in.
+func redirect(w http.ResponseWriter, r *http.Request, req *authorizeRequest, params url.Values) {
+	u, err := url.Parse(req.redirectURI)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "The app that sent you here registered an invalid address.")
+		return
+	}
+	query := u.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	if req.state != "" {
+		query.Set("state", req.state)
+	}
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+type scopeView struct {
+	Scope       string
+	Description string
+}
+
+func renderConsent(w http.ResponseWriter, r *http.Request, req *authorizeRequest) {
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(os.Getenv(FirebaseConfigEnv)), &config); err != nil {
+		renderError(w, http.StatusInternalServerError, "Sign-in isn't configured. Please try again later.")
+		log.Printf("%s: %v", FirebaseConfigEnv, err)
+		return
+	}
+	scopes := make([]scopeView, 0, len(req.scopes))
+	for _, scope := range req.scopes {
+		scopes = append(scopes, scopeView{scope, scopeDescriptions[scope]})
+	}
+	params := url.Values{}
+	for _, name := range authorizeParams {
+		if value := r.Form.Get(name); value != "" {
+			params.Set(name, value)
+		}
+	}
+	render(w, http.StatusOK, consentPage, map[string]interface{}{
+		"Client":   req.client.Name,
+		"Scopes":   scopes,
+		"Params":   params,
+		"Domain":   platform.AllowedEmailDomain,
+		"Firebase": config,
+	})
+}
+
+func renderError(w http.ResponseWriter, status int, message string) {
+	render(w, status, errorPage, map[string]interface{}{"Message": message})
+}
+
+func render(w http.ResponseWriter, status int, page *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := page.Execute(w, data); err != nil {
+		log.Printf("OAuth page rendering failed: %v", err)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>OCTO API</title></head>
+<body>
+<h1>Something went wrong</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// consentPage signs the user in with Firebase and posts their decision, with
+// a fresh ID token, back to the authorize endpoint. The ID token doubles as
+// protection against cross-site form posts.
+var consentPage = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Authorize {{.Client}} - OCTO API</title>
+<script src="https://www.gstatic.com/firebasejs/9.23.0/firebase-app-compat.js"></script>
+<script src="https://www.gstatic.com/firebasejs/9.23.0/firebase-auth-compat.js"></script>
+</head>
+<body>
+<h1>{{.Client}} wants to use your Berkeley account</h1>
+<p>If you allow it, {{.Client}} will be able to:</p>
+<ul>
+{{range .Scopes}}<li>{{if .Description}}{{.Description}}{{else}}{{.Scope}}{{end}}</li>
+{{end}}</ul>
+<form id="consent" method="post">
+{{range $name, $values := .Params}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}<input type="hidden" name="id_token" id="id_token">
+<button type="submit" name="decision" value="allow" id="allow">Sign in with {{.Domain}} and allow</button>
+<button type="submit" name="decision" value="deny">Deny</button>
+</form>
+<p id="error" hidden>Sign-in failed. Please try again.</p>
+<script>
+firebase.initializeApp({{.Firebase}});
+const form = document.getElementById("consent");
+document.getElementById("allow").addEventListener("click", async (event) => {
+  event.preventDefault();
+  const provider = new firebase.auth.GoogleAuthProvider();
+  provider.setCustomParameters({hd: {{.Domain}}});
+  try {
+    const result = await firebase.auth().signInWithPopup(provider);
+    document.getElementById("id_token").value = await result.user.getIdToken();
+    const decision = document.createElement("input");
+    decision.type = "hidden";
+    decision.name = "decision";
+    decision.value = "allow";
+    form.appendChild(decision);
+    form.submit();
+  } catch (err) {
+    document.getElementById("error").hidden = false;
+  }
+});
+</script>
+</body>
+</html>
+`))
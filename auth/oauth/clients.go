@@ -0,0 +1,249 @@
+// Package oauth makes the auth service an OAuth 2.0 authorization server with
+// OpenID Connect, so third-party apps can get access tokens for their users
+// through the authorization code flow with PKCE.
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+const (
+	maxNameLength   = 100
+	maxRedirectURIs = 10
+)
+
+// Client is an OAuth client as shown to its owner. Secret is only set in the
+// response that registers a confidential client.
+type Client struct {
+	ID           string   `json:"client_id"`
+	Secret       string   `json:"client_secret,omitempty"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+	CreatedAt    int64    `json:"created_at"`
+	RevokedAt    *int64   `json:"revoked_at"`
+}
+
+type registerRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+}
+
+type revokeRequest struct {
+	ID string `json:"client_id"`
+}
+
+// ClientsEndpoint lists the caller's OAuth clients on GET and registers one on
+// POST from a body like {"name": "Study Spots", "redirect_uris":
+// ["https://spots.example.com/callback"], "scopes": ["openid", "libraries:read"]}.
+// Single-page and mobile apps, which can't keep a secret, register with
+// "public": true.
+func ClientsEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/oauth/clients", clientsEndpoint, http.MethodGet, http.MethodPost)
+}
+
+func clientsEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateUser(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		listClients(w, r, claims)
+	case http.MethodPost:
+		registerClient(w, r, claims)
+	}
+}
+
+// RevokeClientEndpoint revokes one of the caller's OAuth clients, given a body
+// like {"client_id": "<id>"}. Tokens already issued through it stay valid
+// until they expire.
+func RevokeClientEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/oauth/clients/revoke", revokeClientEndpoint, http.MethodPost)
+}
+
+func revokeClientEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateUser(w, r)
+	if !ok {
+		return
+	}
+	var body revokeRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.ID == "" {
+		platform.MissingBodyParam(w, "client_id")
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	var revoked users.Client
+	err = db.UpdateClient(ctx, body.ID, func(client *users.Client) error {
+		if client.OwnerUID != claims.UID {
+			return users.ErrNotFound
+		}
+		if !client.Revoked() {
+			client.RevokedAt = time.Now().Unix()
+		}
+		revoked = *client
+		return nil
+	})
+	if err == users.ErrNotFound {
+		platform.WriteError(w, http.StatusNotFound, platform.Error{
+			Code:    platform.CodeNotFound,
+			Param:   "client_id",
+			Message: "No OAuth client with id '" + body.ID + "'",
+		})
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("OAuth client revocation failed: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, View(revoked, ""))
+}
+
+func listClients(w http.ResponseWriter, r *http.Request, claims *platform.Claims) {
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	clients, err := db.ListClients(ctx, claims.UID)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("OAuth clients GET failed: %v", err)
+		return
+	}
+	views := make([]Client, 0, len(clients))
+	for _, client := range clients {
+		views = append(views, View(client, ""))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func registerClient(w http.ResponseWriter, r *http.Request, claims *platform.Claims) {
+	var body registerRequest
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > maxNameLength {
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidParam,
+			Param:   "name",
+			Message: fmt.Sprintf("Body param 'name' must be between 1 and %d characters", maxNameLength),
+		})
+		return
+	}
+	if len(body.RedirectURIs) == 0 || len(body.RedirectURIs) > maxRedirectURIs {
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidParam,
+			Param:   "redirect_uris",
+			Message: fmt.Sprintf("Body param 'redirect_uris' must list between 1 and %d URIs", maxRedirectURIs),
+		})
+		return
+	}
+	for _, uri := range body.RedirectURIs {
+		if !platform.ValidRedirectURI(uri) {
+			platform.WriteError(w, http.StatusBadRequest, platform.Error{
+				Code:    platform.CodeInvalidParam,
+				Param:   "redirect_uris",
+				Message: "Redirect URI '" + uri + "' must be an https URL, or http on localhost, without a fragment",
+			})
+			return
+		}
+	}
+	scopes, denied := platform.GrantScopes(body.Scopes, platform.OAuthScopes)
+	if denied != "" {
+		platform.InvalidScope(w, denied)
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	secret, client, err := platform.NewClient(ctx, db, claims.UID, body.Name, body.RedirectURIs, scopes, body.Public)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("OAuth client registration failed: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, View(*client, secret))
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(reqBody, v)
+	}
+	if err != nil {
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidBody,
+			Message: "Invalid body params",
+		})
+		return false
+	}
+	return true
+}
+
+// View returns client as shown to its owner, with its secret when it was
+// just issued.
+func View(client users.Client, secret string) Client {
+	out := Client{
+		ID:           client.ID,
+		Secret:       secret,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		Public:       client.Public(),
+		CreatedAt:    client.CreatedAt,
+	}
+	if client.RevokedAt != 0 {
+		out.RevokedAt = &client.RevokedAt
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	output, err := json.Marshal(v)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("OAuth JSON conversion failed: %v", err)
+		return
+	}
+	w.WriteHeader(status)
+	fmt.Fprint(w, string(output))
+}
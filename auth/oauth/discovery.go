@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/asuc-octo/octoapi/platform"
+)
+
+// Discovery is the OpenID Connect discovery document.
+type Discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// DiscoveryEndpoint serves /.well-known/openid-configuration so OpenID
+// Connect libraries can configure themselves from the issuer URL.
+func DiscoveryEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/.well-known/openid-configuration", discoveryEndpoint, http.MethodGet)
+}
+
+func discoveryEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	alg, err := platform.SigningAlg(r.Context())
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("signing key lookup failed: %v", err)
+		return
+	}
+	issuer, configured := platform.IssuerURL(r)
+	if configured {
+		w.Header().Set("Cache-Control", "public, max-age=600")
+	} else {
+		// An issuer taken from the request's headers mustn't be cached for
+		// other callers.
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	writeJSON(w, http.StatusOK, Discovery{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/v1/oauth/authorize",
+		TokenEndpoint:                     issuer + "/v1/oauth/token",
+		UserInfoEndpoint:                  issuer + "/v1/oauth/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   platform.OAuthScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{alg},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "iat", "exp", "nonce", "email", "email_verified"},
+	})
+}
@@ -0,0 +1,143 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// TokenResponse is a successful token endpoint response (RFC 6749 §5.1).
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// tokenError is a token endpoint error response (RFC 6749 §5.2). OAuth
+// clients expect this shape rather than the API's usual error body.
+type tokenError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// TokenEndpoint exchanges an authorization code for an access token and, when
+// the openid scope was granted, an ID token. Confidential clients
+// authenticate with HTTP Basic or client_secret in the form; public clients
+// send only client_id. No refresh token is issued: apps send the user through
+// the authorize endpoint again once the access token expires.
+func TokenEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/oauth/token", tokenEndpoint, http.MethodPost)
+}
+
+func tokenEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid_request", "The body must be application/x-www-form-urlencoded.")
+		return
+	}
+	if grantType := r.PostForm.Get("grant_type"); grantType != "authorization_code" {
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type", "grant_type must be authorization_code.")
+		return
+	}
+	clientID, secret, basic := clientCredentials(r)
+	if clientID == "" {
+		writeTokenError(w, http.StatusUnauthorized, "invalid_client", "client_id is missing.")
+		return
+	}
+
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	client, err := platform.AuthenticateClient(ctx, db, clientID, secret)
+	if err == platform.ErrInvalidClient {
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="octoapi"`)
+		}
+		writeTokenError(w, http.StatusUnauthorized, "invalid_client", "Unknown client or wrong client secret.")
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("OAuth client authentication failed: %v", err)
+		return
+	}
+
+	code, err := platform.RedeemAuthCode(ctx, db, r.PostForm.Get("code"), client.ID,
+		r.PostForm.Get("redirect_uri"), r.PostForm.Get("code_verifier"))
+	if errors.Is(err, platform.ErrInvalidGrant) {
+		writeTokenError(w, http.StatusBadRequest, "invalid_grant", err.Error())
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("authorization code redemption failed: %v", err)
+		return
+	}
+	user, err := db.Get(ctx, code.UID)
+	if err == nil && user.Blocked {
+		err = platform.ErrAccountBlocked
+	}
+	if err == users.ErrNotFound || err == platform.ErrAccountBlocked {
+		writeTokenError(w, http.StatusBadRequest, "invalid_grant", "The user's account is no longer available.")
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users GET failed: %v", err)
+		return
+	}
+
+	response := TokenResponse{
+		TokenType: "Bearer",
+		ExpiresIn: int64(platform.AccessTokenTTL.Seconds()),
+		Scope:     strings.Join(code.Scopes, " "),
+	}
+	response.AccessToken, err = platform.NewClientAccessToken(ctx, user.UID, client.ID, code.Scopes)
+	if err == nil && contains(code.Scopes, platform.ScopeOpenID) {
+		withEmail := contains(code.Scopes, platform.ScopeEmail)
+		issuer, _ := platform.IssuerURL(r)
+		response.IDToken, err = platform.NewIDToken(ctx, issuer, client.ID, user, code.Nonce, withEmail)
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("OAuth token generation failed: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// clientCredentials returns the client id and secret from HTTP Basic auth,
+// reporting that it was used, or else from the form.
+func clientCredentials(r *http.Request) (string, string, bool) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		// RFC 6749 §2.3.1 form-encodes both before Basic encoding.
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			id = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			secret = unescaped
+		}
+		return id, secret, true
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret"), false
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code, description string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(tokenError{code, description})
+}
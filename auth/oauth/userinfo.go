@@ -0,0 +1,54 @@
+package oauth
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// UserInfo is the OpenID Connect userinfo response.
+type UserInfo struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+}
+
+// UserInfoEndpoint describes the user an access token was issued for. It
+// requires the openid scope; the email address also needs the email scope.
+func UserInfoEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/oauth/userinfo", userInfoEndpoint, http.MethodGet)
+}
+
+func userInfoEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateClaims(w, r, platform.ScopeOpenID)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	user, err := db.Get(ctx, claims.UID)
+	if err == users.ErrNotFound {
+		platform.Unauthorized(w, platform.ErrInvalidToken)
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users GET failed: %v", err)
+		return
+	}
+	info := UserInfo{Subject: user.UID}
+	if claims.HasScope(platform.ScopeEmail) && user.Email != "" {
+		info.Email, info.EmailVerified = user.Email, true
+	}
+	writeJSON(w, http.StatusOK, info)
+}
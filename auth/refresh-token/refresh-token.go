@@ -1,136 +1,135 @@
 package refreshtoken
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"time"
 
-	// "github.com/auth0/go-jwt-middleware"
-	"github.com/dgrijalva/jwt-go"
-
-	"cloud.google.com/go/firestore"
+	"github.com/asuc-octo/octoapi/platform"
 )
 
-type User struct {
-	Uid          string `json:"uid"`
-	Refreshtoken string `json:"refresh-token"`
-	Created_at   int    `json:"created_at"`
-	Blocked      bool   `json:"blocked"`
-	blocked_at   int    `json:"created_at"`
-}
-
 type Tokens struct {
-	AccessToken string `json:"access-token"`
+	AccessToken  string `json:"access-token"`
+	RefreshToken string `json:"refresh-token"`
 }
 
+// RefreshAuthEndpoint exchanges a refresh token for a new access token and a
+// new refresh token. The refresh token sent in is no longer accepted. An
+// optional space-delimited "scope" narrows the access token's scopes.
 func RefreshAuthEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/auth/refresh", refreshAuthEndpoint, http.MethodPost)
+}
+
+func refreshAuthEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	reqBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please make sure you are passing your refresh token in the request body as {“refresh-token”: ‘<token>’}.", http.StatusBadRequest)
+		invalidBody(w)
 		return
 	}
 	var data map[string]interface{}
 	jsonErr := json.Unmarshal([]byte(reqBody), &data)
 	if jsonErr != nil {
-		http.Error(w, "Something went wrong. Please make sure you are passing your refresh token in the request body as {“refresh-token”: ‘<token>’}.", http.StatusBadRequest)
+		invalidBody(w)
 		return
 	}
 	refreshToken, converttoken := data["refresh-token"].(string)
 	if !converttoken {
-		http.Error(w, "Something went wrong. Please make sure you are passing your refresh token in the request body as {“refresh-token”: ‘<token>’}.", http.StatusBadRequest)
+		invalidBody(w)
+		return
+	}
+	scope, convertscope := data["scope"].(string)
+	if !convertscope && data["scope"] != nil {
+		platform.InvalidParam(w, "scope")
+		return
+	}
+	ctx := r.Context()
+	claims, decodeErr := platform.ParseToken(ctx, refreshToken, platform.TokenTypeRefresh)
+	if platform.IsTokenError(decodeErr) {
+		invalidRefreshToken(w, decodeErr)
 		return
 	}
-	uid, decodeErr := decodeRefreshToken(refreshToken)
 	if decodeErr != nil {
-		http.Error(w, "Something went wrong. Please make sure you are passing your refresh token in the request body as {“refresh-token”: ‘<token>’}.", http.StatusBadRequest)
+		platform.InternalError(w)
+		log.Printf("refresh token decoding failed: %v", decodeErr)
 		return
 	}
 
-	client, ctx, clientErr := initFirestore(w)
-	if clientErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
-		log.Printf("firestore init failed: %v", clientErr)
+	// The access token may be narrowed to fewer scopes than the refresh
+	// token grants, never widened.
+	requested := platform.ParseScope(scope)
+	if len(requested) > 0 {
+		if _, denied := platform.GrantScopes(requested, platform.ParseScope(claims.Scope)); denied != "" {
+			platform.InvalidScope(w, denied)
+			return
+		}
+	}
+
+	db, dbErr := platform.OpenUsers(ctx)
+	if dbErr != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", dbErr)
+		return
+	}
+	defer db.Close()
+	newRefreshToken, granted, rotateErr := platform.RotateRefreshToken(ctx, db, claims)
+	if rotateErr == platform.ErrAccountBlocked {
+		platform.Forbidden(w)
+		return
+	}
+	if platform.IsTokenError(rotateErr) {
+		invalidRefreshToken(w, rotateErr)
+		return
+	}
+	if rotateErr != nil {
+		platform.InternalError(w)
+		log.Printf("refresh token rotation failed: %v", rotateErr)
 		return
 	}
-	token, tokenErr := getAccessToken(uid, client, ctx)
+	scopes := granted
+	if len(requested) > 0 {
+		scopes = platform.IntersectScopes(requested, granted)
+	}
+	token, tokenErr := platform.NewAccessToken(ctx, claims.UID, scopes)
 	if tokenErr != nil {
-		http.Error(w, tokenErr.Error(), http.StatusBadRequest)
+		platform.InternalError(w)
+		log.Printf("access token generation failed: %v", tokenErr)
 		return
 	}
-	tokens := Tokens{token}
+	tokens := Tokens{token, newRefreshToken}
 	tokensJSON, jsonErr := json.Marshal(tokens)
 	if jsonErr != nil {
-		http.Error(w, "Something went wrong. Please make sure you are passing your refresh token in the request body as {“refresh-token”: ‘<token>’}.", http.StatusBadRequest)
+		platform.InternalError(w)
 		log.Printf("token generation failed: %v", jsonErr)
 		return
 	}
 	w.Write(tokensJSON)
 }
 
-func decodeRefreshToken(refreshtoken string) (string, error) {
-	claims := jwt.MapClaims{}
+const refreshTokenUsage = "Something went wrong. Please make sure you are passing your refresh token in the request body as {“refresh-token”: ‘<token>’}."
 
-	jwtTokenSecret, jwterr := getJwtSecret()
-	if jwterr != nil {
-		return "", jwterr
-	}
+const loginAgain = "Your refresh token has expired or been revoked. Please log in again to get a new one."
 
-	_, err := jwt.ParseWithClaims(refreshtoken, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtTokenSecret, nil
+func invalidBody(w http.ResponseWriter) {
+	platform.WriteError(w, http.StatusBadRequest, platform.Error{
+		Code:    platform.CodeInvalidBody,
+		Message: refreshTokenUsage,
 	})
-	if err != nil {
-		return "", err
-	}
-	if claims["type"] != "refresh" {
-		return "", errors.New("invalid refresh token")
-	}
-	return claims["uid"].(string), nil
 }
 
-func getAccessToken(uid string, client *firestore.Client, ctx context.Context) (string, error) {
-	defer client.Close()
-
-	// first check if user already exists in the database
-	userQuery, queryErr := client.Collection("users").Doc(uid).Get(ctx)
-	if queryErr != nil {
-		return "", errors.New("Something went wrong. Please make sure you are passing your refresh token in the request body as {“refresh-token”: ‘<token>’}.")
-	}
-	userData := userQuery.Data()
-	if userData["blocked"].(bool) {
-		return "", errors.New("Your account has been blocked. If you believe something went wrong, please contact octo.api@asuc.org for details.")
+func invalidRefreshToken(w http.ResponseWriter, err error) {
+	code, message := platform.CodeInvalidToken, refreshTokenUsage
+	switch {
+	case errors.Is(err, platform.ErrExpiredToken):
+		code, message = platform.CodeExpiredToken, loginAgain
+	case errors.Is(err, platform.ErrRevokedToken), errors.Is(err, platform.ErrReusedToken):
+		message = loginAgain
 	}
-	newJwtToken, tokenGenErr := getAccessToken(uid)
-	if tokenGenErr != nil {
-		return "", tokenGenErr
-	}
-	return newJwtToken, nil
-}
-
-func getAccessToken(uid string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"uid":  uid,
-		"type": "access",
-		"exp":  time.Now().Add(time.Hour * 72).Unix(),
+	w.Header().Set("WWW-Authenticate", `Bearer realm="octoapi", error="invalid_token", error_description="`+err.Error()+`"`)
+	platform.WriteError(w, http.StatusUnauthorized, platform.Error{
+		Code:    code,
+		Message: message,
 	})
-	jwtTokenSecret, err := getJwtSecret()
-	if err != nil {
-		return "", err
-	}
-	tokenString, err := token.SignedString(jwtTokenSecret)
-	return tokenString, err
-}
-
-func initFirestore(w http.ResponseWriter) (*firestore.Client, context.Context, error) {
-	// Use the application default credentials
-	ctx := context.Background()
-	client, clientErr := firestore.NewClient(ctx, "api-team-292919")
-	if clientErr != nil {
-		return nil, nil, clientErr
-	}
-	return client, ctx, nil
 }
@@ -0,0 +1,71 @@
+package revoketoken
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/asuc-octo/octoapi/platform"
+)
+
+const revokeTokenUsage = "Please pass the refresh token to revoke in the request body as {“refresh-token”: ‘<token>’}."
+
+// RevokeTokenEndpoint revokes a refresh token along with every token rotated
+// from the same login. As in RFC 7009, tokens that are already invalid are
+// accepted silently.
+func RevokeTokenEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/auth/revoke", revokeTokenEndpoint, http.MethodPost)
+}
+
+func revokeTokenEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		invalidBody(w)
+		return
+	}
+	var data map[string]interface{}
+	if jsonErr := json.Unmarshal(reqBody, &data); jsonErr != nil {
+		invalidBody(w)
+		return
+	}
+	refreshToken, converttoken := data["refresh-token"].(string)
+	if !converttoken {
+		invalidBody(w)
+		return
+	}
+
+	ctx := r.Context()
+	claims, decodeErr := platform.ParseToken(ctx, refreshToken, platform.TokenTypeRefresh)
+	if platform.IsTokenError(decodeErr) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if decodeErr != nil {
+		platform.InternalError(w)
+		log.Printf("refresh token decoding failed: %v", decodeErr)
+		return
+	}
+	db, dbErr := platform.OpenUsers(ctx)
+	if dbErr != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", dbErr)
+		return
+	}
+	defer db.Close()
+	if revokeErr := platform.RevokeRefreshToken(ctx, db, claims); revokeErr != nil {
+		platform.InternalError(w)
+		log.Printf("refresh token revocation failed: %v", revokeErr)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func invalidBody(w http.ResponseWriter) {
+	platform.WriteError(w, http.StatusBadRequest, platform.Error{
+		Code:    platform.CodeInvalidBody,
+		Message: revokeTokenUsage,
+	})
+}
@@ -0,0 +1,44 @@
+// Command octoapi-geohash sets the geohash field of every gym, library,
+// dining hall and campus resource in Firestore from its latitude and
+// longitude. Location queries read whole collections until every document
+// has an up-to-date geohash, so run it after adding or moving places.
+//
+// It writes to the shared berkeley-mobile project, so by default it only
+// reports how many documents it would update; pass -write to update them.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+func main() {
+	write := flag.Bool("write", false, "update the documents instead of only counting them")
+	flag.Parse()
+
+	ctx := context.Background()
+	client, err := platform.NewFirestoreClient(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db := store.NewFirestore(client)
+	defer db.Close()
+	for _, collection := range []string{store.Gyms, store.Libraries, store.DiningHalls, store.Resources} {
+		updated, err := db.IndexGeohashes(ctx, collection, !*write)
+		if err != nil {
+			log.Fatalf("%s: %v", collection, err)
+		}
+		if *write {
+			log.Printf("%s: updated %d documents", collection, updated)
+		} else {
+			log.Printf("%s: would update %d documents", collection, updated)
+		}
+	}
+	if !*write {
+		log.Print("dry run: pass -write to update the documents")
+	}
+}
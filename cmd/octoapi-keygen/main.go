@@ -0,0 +1,73 @@
+// Command octoapi-keygen generates a token signing key. It prints the entry
+// to add to the keys of the jwt_signing_keys secret and the JWK to add to the
+// jwt_public_keys secret.
+//
+// To roll keys without downtime, publish the new JWK first, wait for the key
+// cache to expire (10 minutes), then add the signing entry and make it
+// active. Remove the old JWK once every token it signed has expired.
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+)
+
+func main() {
+	alg := flag.String("alg", "ES256", "signing algorithm, RS256 or ES256")
+	kid := flag.String("kid", time.Now().Format("2006-01-02"), "key id")
+	flag.Parse()
+
+	var key crypto.Signer
+	var block *pem.Block
+	switch *alg {
+	case "RS256":
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			log.Fatal(err)
+		}
+		key = rsaKey
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}
+	case "ES256":
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			log.Fatal(err)
+		}
+		der, err := x509.MarshalECPrivateKey(ecKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		key = ecKey
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	default:
+		log.Fatalf("unsupported algorithm %q", *alg)
+	}
+	jwk, err := platform.PublicJWK(*kid, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	err = out.Encode(map[string]interface{}{
+		"signing_key": map[string]string{
+			"kid":         *kid,
+			"alg":         *alg,
+			"private_key": string(pem.EncodeToMemory(block)),
+		},
+		"public_key": jwk,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
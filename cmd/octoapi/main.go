@@ -0,0 +1,62 @@
+// Command octoapi serves every OCTO API endpoint from a single process, for
+// running the whole API locally, in a container or on a VM.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+)
+
+func main() {
+	addr := flag.String("addr", defaultAddr(), "address to listen on")
+	flag.Parse()
+
+	// Every endpoint runs in this process, so in-memory usage records see all
+	// of them.
+	if os.Getenv(platform.UsageSinkEnv) == "" {
+		os.Setenv(platform.UsageSinkEnv, "memory")
+	}
+	if _, err := platform.UsageSink(context.Background()); err != nil {
+		log.Fatalf("usage sink: %v", err)
+	}
+	platform.WarnAdminCORS()
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newRouter(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("octoapi listening on %s", *addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen failed: %v", err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("shutdown failed: %v", err)
+	}
+}
+
+// defaultAddr honors the PORT variable set by most container platforms.
+func defaultAddr() string {
+	if port := os.Getenv("PORT"); port != "" {
+		return ":" + port
+	}
+	return ":8080"
+}
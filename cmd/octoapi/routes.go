@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+
+	adminaudit "github.com/asuc-octo/octoapi/admin/admin-audit"
+	adminusage "github.com/asuc-octo/octoapi/admin/admin-usage"
+	adminusers "github.com/asuc-octo/octoapi/admin/admin-users"
+	apikeys "github.com/asuc-octo/octoapi/auth/api-keys"
+	"github.com/asuc-octo/octoapi/auth/jwks"
+	"github.com/asuc-octo/octoapi/auth/login"
+	"github.com/asuc-octo/octoapi/auth/oauth"
+	refreshtoken "github.com/asuc-octo/octoapi/auth/refresh-token"
+	revoketoken "github.com/asuc-octo/octoapi/auth/revoke-token"
+	"github.com/asuc-octo/octoapi/dining/dining"
+	dininghours "github.com/asuc-octo/octoapi/dining/dining-hours"
+	dininglocation "github.com/asuc-octo/octoapi/dining/dining-location"
+	diningsearch "github.com/asuc-octo/octoapi/dining/dining-search"
+	"github.com/asuc-octo/octoapi/gyms/gyms"
+	gymsfacilities "github.com/asuc-octo/octoapi/gyms/gyms-facilities"
+	gymshours "github.com/asuc-octo/octoapi/gyms/gyms-hours"
+	gymslocation "github.com/asuc-octo/octoapi/gyms/gyms-location"
+	gymsopen "github.com/asuc-octo/octoapi/gyms/gyms-open"
+	gymssearch "github.com/asuc-octo/octoapi/gyms/gyms-search"
+	"github.com/asuc-octo/octoapi/libraries/libraries"
+	librarieshours "github.com/asuc-octo/octoapi/libraries/libraries-hours"
+	librarieslocation "github.com/asuc-octo/octoapi/libraries/libraries-location"
+	librariesopen "github.com/asuc-octo/octoapi/libraries/libraries-open"
+	librariessearch "github.com/asuc-octo/octoapi/libraries/libraries-search"
+	nearbyopen "github.com/asuc-octo/octoapi/nearby/nearby-open"
+	"github.com/asuc-octo/octoapi/portal/portal"
+	"github.com/asuc-octo/octoapi/resources/resources"
+	resourceshours "github.com/asuc-octo/octoapi/resources/resources-hours"
+	resourceslocation "github.com/asuc-octo/octoapi/resources/resources-location"
+	resourcesopen "github.com/asuc-octo/octoapi/resources/resources-open"
+	resourcessearch "github.com/asuc-octo/octoapi/resources/resources-search"
+	transitallroutes "github.com/asuc-octo/octoapi/transit/transit-all-routes"
+	transitallstops "github.com/asuc-octo/octoapi/transit/transit-all-stops"
+	transitroutebyname "github.com/asuc-octo/octoapi/transit/transit-route-by-name"
+	transitroutebystop "github.com/asuc-octo/octoapi/transit/transit-route-by-stop"
+	"github.com/asuc-octo/octoapi/usage/usage"
+	"github.com/asuc-octo/octoapi/weather/weather"
+)
+
+// route maps a versioned path onto one of the Cloud Function entry points,
+// which apply their own CORS policy and metering.
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+var routes = []route{
+	{"/v1/auth/login", login.AuthEndpoint},
+	{"/v1/auth/refresh", refreshtoken.RefreshAuthEndpoint},
+	{"/v1/auth/revoke", revoketoken.RevokeTokenEndpoint},
+	{"/v1/auth/keys", apikeys.APIKeysEndpoint},
+	{"/v1/auth/keys/revoke", apikeys.RevokeAPIKeyEndpoint},
+	{"/v1/auth/keys/regenerate", apikeys.RegenerateAPIKeyEndpoint},
+	{"/.well-known/jwks.json", jwks.JWKSEndpoint},
+
+	{"/v1/oauth/authorize", oauth.AuthorizeEndpoint},
+	{"/v1/oauth/token", oauth.TokenEndpoint},
+	{"/v1/oauth/userinfo", oauth.UserInfoEndpoint},
+	{"/v1/oauth/clients", oauth.ClientsEndpoint},
+	{"/v1/oauth/clients/revoke", oauth.RevokeClientEndpoint},
+	{"/.well-known/openid-configuration", oauth.DiscoveryEndpoint},
+
+	{"/v1/admin/users", adminusers.ListUsersEndpoint},
+	{"/v1/admin/users/block", adminusers.BlockUserEndpoint},
+	{"/v1/admin/users/unblock", adminusers.UnblockUserEndpoint},
+	{"/v1/admin/audit", adminaudit.AuditLogEndpoint},
+	{"/v1/admin/usage", adminusage.AdminUsageEndpoint},
+	{"/v1/usage", usage.UsageEndpoint},
+	{"/v1/portal/credentials", portal.CredentialsEndpoint},
+	{"/v1/portal/session/revoke", portal.RevokeSessionEndpoint},
+
+	{"/v1/gyms", gyms.GymEndpoint},
+	{"/v1/gyms/open", gymsopen.GymOpenEndpoint},
+	{"/v1/gyms/facilities/open", gymsfacilities.GymFacilitiesOpenEndpoint},
+	{"/v1/gyms/search", gymssearch.GymSearchEndpoint},
+	{"/v1/gyms/location", gymslocation.GymLocationsEndpoint},
+	{"/v1/gyms/{name}/hours", gymshours.GymHoursEndpoint},
+
+	{"/v1/libraries", libraries.LibraryEndpoint},
+	{"/v1/libraries/open", librariesopen.LibraryOpenEndpoint},
+	{"/v1/libraries/search", librariessearch.LibrarySearchEndpoint},
+	{"/v1/libraries/location", librarieslocation.LibrariesLocationEndpoint},
+	{"/v1/libraries/{name}/hours", librarieshours.LibraryHoursEndpoint},
+
+	{"/v1/dining", dining.DiningEndpoint},
+	{"/v1/dining/search", diningsearch.DiningSearchEndpoint},
+	{"/v1/dining/location", dininglocation.DiningLocationEndpoint},
+	{"/v1/dining/{name}/hours", dininghours.DiningHoursEndpoint},
+
+	{"/v1/resources", resources.CampusResourceEndpoint},
+	{"/v1/resources/open", resourcesopen.ResourcesOpenEndpoint},
+	{"/v1/resources/search", resourcessearch.ResourcesSearchEndpoint},
+	{"/v1/resources/location", resourceslocation.ResourcesLocationEndpoint},
+	{"/v1/resources/{name}/hours", resourceshours.ResourceHoursEndpoint},
+
+	{"/v1/nearby/open", nearbyopen.NearbyOpenEndpoint},
+
+	{"/v1/transit/routes", transitallroutes.TransitAllRoutesEndpoint},
+	{"/v1/transit/routes/by-name", transitroutebyname.TransitRouteByName},
+	{"/v1/transit/routes/by-stop", transitroutebystop.TransitRouteByStopEndpoint},
+	{"/v1/transit/stops", transitallstops.TransitAllStopsEndpoint},
+
+	{"/v1/weather", weather.WeatherEndpoint},
+}
+
+func newRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		mux.HandleFunc(rt.pattern, rt.handler)
+	}
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
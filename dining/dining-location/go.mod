@@ -1,14 +0,0 @@
-module example.com/cloudfunction
-
-go 1.15
-
-require (
-	cloud.google.com/go v0.66.0
-	cloud.google.com/go/firestore v1.3.0
-	cloud.google.com/go/storage v1.12.0
-	github.com/gorilla/schema v1.2.0
-	github.com/martinlindhe/unit v0.0.0-20190604142932-3b6be53d49af
-	github.com/umahmood/haversine v0.0.0-20151105152445-808ab04add26
-	google.golang.org/api v0.35.0
-	google.golang.org/genproto v0.0.0-20200921151605-7abf4a1a14d5
-)
@@ -7,89 +7,85 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
-	"cloud.google.com/go/firestore"
-	"google.golang.org/api/iterator"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-// type Dining struct {
-// 	Name string `json:"name"`
-//     Latitude float64 `json:"latitude"`
-//     Longitude float64 `json:"longitude"`
-//     Phone string `json:"phone"`
-//     Description string `json:"description"`
-//     Address string `json:"address"`
-// }
+// DiningSearchEndpoint lists the dining halls whose name contains the name
+// param, ignoring case, or 404 when none do.
+func DiningSearchEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/dining/search", diningSearchEndpoint, http.MethodGet)
+}
 
-var DiningFields = [6]string{"name", "description", "latitude", "longitude", "address", "phone"}
-var client *firestore.Client
-var ctx context.Context
+func diningSearchEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-func DiningSearchEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
+	if !platform.Authenticate(w, r, platform.ScopeDiningRead) {
 		return
 	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
 	name := strings.ToLower(r.URL.Query().Get("name"))
 	if name == "" {
-		http.Error(w, "Url Param 'name' is missing", http.StatusBadRequest)
+		platform.MissingParam(w, "name")
 		return
 	}
-	fstoreErr := initFirestore(w)
+	ctx := r.Context()
+	db, fstoreErr := platform.OpenStore(ctx)
 	if fstoreErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
-		log.Printf("Firestore Init failed: %v", fstoreErr)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", fstoreErr)
 		return
 	}
-	dinings, diningErr := searchDinings(ctx, w, client, name)
+	defer db.Close()
+	dinings, diningErr := searchDinings(ctx, db, at, name)
 	if diningErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("dining search GET failed: %v", diningErr)
 		return
 	}
+	if len(dinings) == 0 {
+		platform.WriteError(w, http.StatusNotFound, platform.Error{
+			Code:    platform.CodeNotFound,
+			Param:   "name",
+			Message: "No dining hall matching '" + r.URL.Query().Get("name") + "'",
+		})
+		return
+	}
 	output, jsonErr := json.Marshal(&dinings)
 	if jsonErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("dining JSON conversion failed: %v", jsonErr)
 		return
 	}
 	fmt.Fprint(w, string(output))
 }
 
-func searchDinings(ctx context.Context, w http.ResponseWriter, client *firestore.Client, name string) ([]map[string]interface{}, error) {
-	defer client.Close()
-	dinings := make([]map[string]interface{}, 0)
-	iter := client.Collection("Dining Halls").Documents(ctx)
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
+func searchDinings(ctx context.Context, db store.Store, at time.Time, name string) ([]models.DiningHall, error) {
+	dinings := make([]models.DiningHall, 0)
+	docs, err := db.List(ctx, store.DiningHalls)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, docData := range docs {
+		dining, err := models.DecodeDiningHall(docData)
 		if err != nil {
-			return nil, err
+			log.Printf("Skipping invalid dining hall: %v", err)
+			continue
 		}
-		docData := doc.Data()
-		if strings.Contains(strings.ToLower(fmt.Sprintf("%s", docData["name"])), name) {
-			dining := make(map[string]interface{})
-			for _, element := range DiningFields {
-				dining[element] = docData[element]
-			}
+		dining.Evaluate(at, holidays)
+		if strings.Contains(strings.ToLower(dining.Name), name) {
 			dinings = append(dinings, dining)
 		}
 	}
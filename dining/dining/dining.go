@@ -6,83 +6,69 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
-	"cloud.google.com/go/firestore"
-	"google.golang.org/api/iterator"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-// type Dining struct {
-// 	Name string `json:"name"`
-//     Latitude float64 `json:"latitude"`
-//     Longitude float64 `json:"longitude"`
-//     Phone string `json:"phone"`
-//     Description string `json:"description"`
-//     Address string `json:"address"`
-// }
+func DiningEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/dining", diningEndpoint, http.MethodGet)
+}
 
-var DiningFields = [6]string{"name", "description", "latitude", "longitude", "address", "phone"}
-var client *firestore.Client
-var ctx context.Context
+func diningEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-func DiningEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
+	if !platform.Authenticate(w, r, platform.ScopeDiningRead) {
 		return
 	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
-	fstoreErr := initFirestore(w)
+	ctx := r.Context()
+	db, fstoreErr := platform.OpenStore(ctx)
 	if fstoreErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
-		log.Printf("Firestore Init failed: %v", fstoreErr)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", fstoreErr)
 		return
 	}
-	dinings, diningErr := listDinings(ctx, w, client)
+	defer db.Close()
+	dinings, diningErr := listDinings(ctx, db, at)
 	if diningErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("dining GET failed: %v", diningErr)
 		return
 	}
 	output, jsonErr := json.Marshal(&dinings)
 	if jsonErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("libraries JSON conversion failed: %v", jsonErr)
 		return
 	}
 	fmt.Fprint(w, string(output))
 }
 
-func listDinings(ctx context.Context, w http.ResponseWriter, client *firestore.Client) ([]map[string]interface{}, error) {
-	defer client.Close()
-	var dinings []map[string]interface{}
-	iter := client.Collection("Dining Halls").Documents(ctx)
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
+func listDinings(ctx context.Context, db store.Store, at time.Time) ([]models.DiningHall, error) {
+	var dinings []models.DiningHall
+	docs, err := db.List(ctx, store.DiningHalls)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, docData := range docs {
+		dining, err := models.DecodeDiningHall(docData)
 		if err != nil {
-			return nil, err
-		}
-		docData := doc.Data()
-		dining := make(map[string]interface{})
-		for _, element := range DiningFields {
-			dining[element] = docData[element]
+			log.Printf("Skipping invalid dining hall: %v", err)
+			continue
 		}
+		dining.Evaluate(at, holidays)
 		dinings = append(dinings, dining)
 	}
 	return dinings, nil
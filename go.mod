@@ -0,0 +1,38 @@
+module github.com/asuc-octo/octoapi
+
+go 1.22
+
+require (
+	cloud.google.com/go v0.72.0
+	cloud.google.com/go/firestore v1.3.0
+	firebase.google.com/go v3.13.0+incompatible
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/gorilla/schema v1.2.0
+	github.com/martinlindhe/unit v0.0.0-20190604142932-3b6be53d49af
+	github.com/sendgrid/sendgrid-go v3.16.1+incompatible
+	github.com/umahmood/haversine v0.0.0-20151105152445-808ab04add26
+	google.golang.org/api v0.35.0
+	google.golang.org/genproto v0.0.0-20201117123952-62d171c70ae1
+	google.golang.org/grpc v1.33.2
+)
+
+require (
+	cloud.google.com/go/storage v1.10.0 // indirect
+	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
+	github.com/golang/protobuf v1.4.3 // indirect
+	github.com/google/go-cmp v0.5.2 // indirect
+	github.com/googleapis/gax-go/v2 v2.0.5 // indirect
+	github.com/jstemmer/go-junit-report v0.9.1 // indirect
+	github.com/sendgrid/rest v2.6.9+incompatible // indirect
+	go.opencensus.io v0.22.5 // indirect
+	golang.org/x/lint v0.0.0-20200302205851-738671d3881b // indirect
+	golang.org/x/mod v0.3.0 // indirect
+	golang.org/x/net v0.0.0-20201031054903-ff519b6c9102 // indirect
+	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43 // indirect
+	golang.org/x/sys v0.0.0-20200930185726-fdedc70b468f // indirect
+	golang.org/x/text v0.3.4 // indirect
+	golang.org/x/tools v0.0.0-20201110124207-079ba7bd75cd // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	google.golang.org/appengine v1.6.6 // indirect
+	google.golang.org/protobuf v1.25.0 // indirect
+)
@@ -0,0 +1,96 @@
+package gymsfacilities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+// Facility is a gym's pool or track with its status at the requested time.
+type Facility struct {
+	Gym      string `json:"gym"`
+	Facility string `json:"facility"`
+	models.Status
+}
+
+// GymFacilitiesOpenEndpoint lists the pools and tracks open at the time
+// param, with when each closes. facility=pool or facility=track lists only
+// one kind.
+func GymFacilitiesOpenEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/gyms/facilities/open", gymFacilitiesOpenEndpoint, http.MethodGet)
+}
+
+func gymFacilitiesOpenEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !platform.Authenticate(w, r, platform.ScopeGymsRead) {
+		return
+	}
+
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
+		return
+	}
+	facilities := []string{models.FacilityPool, models.FacilityTrack}
+	switch facility := r.URL.Query().Get("facility"); facility {
+	case "":
+	case models.FacilityPool, models.FacilityTrack:
+		facilities = []string{facility}
+	default:
+		platform.InvalidParam(w, "facility")
+		return
+	}
+	db, err := platform.OpenStore(r.Context())
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	open, err := getOpenFacilities(r.Context(), db, at, facilities)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("Get Open Gym Facilities failed: %v", err)
+		return
+	}
+	output, err := json.Marshal(open)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("Couldn't convert gym facilities to JSON: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
+
+func getOpenFacilities(ctx context.Context, db store.Store, at time.Time, facilities []string) ([]Facility, error) {
+	docs, err := db.List(ctx, store.Gyms)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	open := make([]Facility, 0)
+	for _, docData := range docs {
+		gym, err := models.DecodeGym(docData)
+		if err != nil {
+			log.Printf("Skipping invalid gym: %v", err)
+			continue
+		}
+		for _, facility := range facilities {
+			status := models.StatusAt(gym.FacilitySchedule(facility, holidays), at)
+			if status.IsOpen {
+				open = append(open, Facility{Gym: gym.Name, Facility: facility, Status: status})
+			}
+		}
+	}
+	return open, nil
+}
@@ -0,0 +1,92 @@
+package gymshours
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+// GymHoursEndpoint returns the opening hours of one gym for each day of a date
+// range, this week by default, so apps can render an hours table. The facility
+// param picks the building (the default), pool or track.
+func GymHoursEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/gyms/{name}/hours", gymHoursEndpoint, http.MethodGet)
+}
+
+func gymHoursEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !platform.Authenticate(w, r, platform.ScopeGymsRead) {
+		return
+	}
+
+	name, ok := platform.NameParam(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := platform.DateRangeParams(w, r)
+	if !ok {
+		return
+	}
+	facility := r.URL.Query().Get("facility")
+	if facility == "" {
+		facility = models.FacilityBuilding
+	}
+	if !models.ValidGymFacility(facility) {
+		platform.InvalidParam(w, "facility")
+		return
+	}
+	ctx := r.Context()
+	db, err := platform.OpenStore(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	table, err := getGymHours(ctx, db, name, facility, from, to)
+	if err == store.ErrNotFound {
+		platform.WriteError(w, http.StatusNotFound, platform.Error{
+			Code:    platform.CodeNotFound,
+			Param:   "name",
+			Message: "No gym named '" + name + "'",
+		})
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("gym hours GET failed: %v", err)
+		return
+	}
+	output, err := json.Marshal(table)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("gym hours JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
+
+func getGymHours(ctx context.Context, db store.Store, name, facility string, from, to time.Time) (*models.Timetable, error) {
+	doc, err := db.GetByName(ctx, store.Gyms, name)
+	if err != nil {
+		return nil, err
+	}
+	gym, err := models.DecodeGym(doc)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	table := models.NewTimetable(gym.Name, gym.FacilitySchedule(facility, holidays), from, to)
+	return &table, nil
+}
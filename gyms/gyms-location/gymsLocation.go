@@ -3,201 +3,93 @@ package gymslocation
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
+	"time"
 
-	"cloud.google.com/go/firestore"
-	"github.com/gorilla/schema"
-	"github.com/martinlindhe/unit"
-	"github.com/umahmood/haversine"
-	"google.golang.org/api/iterator"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-// type Timing struct {
-//     Open_Time int64 `json:"open_time"`
-//     Close_Time int64 `json:"close_time"`
-// }
-
-// type Gym struct {
-//     Name string `json:"name"`
-//     Latitude float64 `json:"latitude"`
-//     Longitude float64 `json:"longitude"`
-//     Phone string `json:"phone"`
-//     Description string `json:"description"`
-//     Open_Close_Hours []Timing `json:"open_close_hours"`
-//     Track_Hours []Timing `json:"track_hours"`
-//     Pool_Hours []Timing `json:"pool_hours"`
-// }
-
-var decoder = schema.NewDecoder()
-var client *firestore.Client
-var ctx context.Context
-var GymFields = [...]string{"name", "description", "latitude", "longitude", "address", "phone", "open_close_array", "track_hours", "pool_hours"}
-var unitMap = map[string]unit.Length{
-	"ft": unit.Foot,
-	"yd": unit.Yard,
-	"mi": unit.Mile,
-	"m":  unit.Meter,
-	"km": unit.Kilometer,
+// Gym is a gym with its distance from the requested point in the requested
+// unit.
+type Gym struct {
+	models.Gym
+	Distance float64 `json:"distance"`
 }
 
 func GymLocationsEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
+	platform.Serve(w, r, "/v1/gyms/location", gymLocationsEndpoint, http.MethodGet)
+}
+
+func gymLocationsEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	if !platform.Authenticate(w, r, platform.ScopeGymsRead) {
 		return
 	}
 
-	var radius float64
-	var longitude float64
-	var latitude float64
-	var units string
-	var err error
-	radiusInput, ok := r.URL.Query()["radius"]
-	if ok {
-		if len(radiusInput[0]) >= 1 {
-			radius, err = strconv.ParseFloat(radiusInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'radius' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(radiusInput[0]) < 1 {
-			http.Error(w, "Url Param 'radius' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'radius' is missing", http.StatusBadRequest)
-		return
-	}
-	longitudeInput, ok := r.URL.Query()["longitude"]
-	if ok {
-		if len(longitudeInput[0]) >= 1 {
-			longitude, err = strconv.ParseFloat(longitudeInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'longitude' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(longitudeInput[0]) < 1 {
-			http.Error(w, "Url Param 'longitude' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'longitude' is missing", http.StatusBadRequest)
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
-	latitudeInput, ok := r.URL.Query()["latitude"]
-	if ok {
-		if len(latitudeInput[0]) >= 1 {
-			latitude, err = strconv.ParseFloat(latitudeInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'latitude' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(latitudeInput[0]) < 1 {
-			http.Error(w, "Url Param 'latitude' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'latitude' is missing", http.StatusBadRequest)
+	area, ok := platform.AreaParams(w, r)
+	if !ok {
 		return
 	}
-
-	unitsInput, ok := r.URL.Query()["unit"]
-	if !ok || len(unitsInput) < 1 {
-		http.Error(w, "Url Param 'unit' is missing", http.StatusBadRequest)
+	page, ok := platform.PageParams(w, r)
+	if !ok {
 		return
 	}
-	units = unitsInput[0]
 
-	var kilometers float64
-	kilometers, err = convertToKilometers(radius, units)
+	db, err := platform.OpenStore(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
 		return
 	}
-
-	err = initFirestore(w)
-
-	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
-		log.Printf("Firestore Init failed: %v", err)
-		return
-	}
-	// Distance range
+	defer db.Close()
 	var output []byte
-	var gyms []map[string]interface{}
-	gyms, err = getGymsInRadius(w, longitude, latitude, kilometers)
+	var gyms []Gym
+	gyms, err = getGymsInRadius(r.Context(), db, at, area)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("Get Gyms in Radius failed: %v", err)
 		return
 	}
-	output, err = json.Marshal(gyms)
+	start, end := page.Bounds(len(gyms))
+	output, err = json.Marshal(gyms[start:end])
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("Couldn't convert gym to JSON: %v", err)
 		return
 	}
 	fmt.Fprint(w, string(output))
 }
 
-// radius in meters
-func getGymsInRadius(w http.ResponseWriter, longitude float64, latitude float64, radius float64) ([]map[string]interface{}, error) {
-	defer client.Close()
-	gyms := make([]map[string]interface{}, 0)
-	iter := client.Collection("Gyms").Documents(ctx)
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
+// getGymsInRadius returns the gyms in area, nearest first.
+func getGymsInRadius(ctx context.Context, db store.Store, at time.Time, area platform.Area) ([]Gym, error) {
+	docs, err := area.Find(ctx, db, store.Gyms)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	gyms := make([]Gym, 0)
+	for _, docData := range docs {
+		gym, err := models.DecodeGym(docData)
 		if err != nil {
-			return nil, err
-		}
-		docData := doc.Data()
-		gym := make(map[string]interface{})
-		for _, element := range GymFields {
-			gym[element] = docData[element]
-		}
-		gymLatitude, okLatitude := gym["latitude"].(float64)
-		gymLongitude, okLongitude := gym["longitude"].(float64)
-		if !okLatitude {
-			log.Printf("Latitude cannot be parsed: %v", gym)
-		} else if !okLongitude {
-			log.Printf("Longitude cannot be parsed: %v", gym)
-		} else {
-			_, km := haversine.Distance(haversine.Coord{Lat: latitude, Lon: longitude}, haversine.Coord{Lat: gymLatitude, Lon: gymLongitude})
-			if km <= radius {
-				gyms = append(gyms, gym)
-			}
+			log.Printf("Skipping invalid gym: %v", err)
+			continue
 		}
+		gym.Evaluate(at, holidays)
+		distance := area.Distance(gym.Latitude, gym.Longitude)
+		gyms = append(gyms, Gym{Gym: gym, Distance: distance})
 	}
 	return gyms, nil
 }
-
-func convertToKilometers(value float64, units string) (float64, error) {
-	unitConvert, valid := unitMap[units]
-	if valid {
-		return (unit.Length(value) * unitConvert).Kilometers(), nil
-	} else {
-		return 0, errors.New("URL Param 'unit' is incorrect")
-	}
-}
@@ -0,0 +1,61 @@
+package gymsopen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+func TestGetGymsOpenFacility(t *testing.T) {
+	// The building opens at 8:00 and the pool at 6:00 the same morning.
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC).Unix()
+	db := store.NewMemory(map[string][]store.Document{
+		store.Gyms: {{
+			"name":      "RSF",
+			"latitude":  37.8685,
+			"longitude": -122.2625,
+			"open_close_array": []interface{}{
+				map[string]interface{}{"open_time": float64(day + 8*3600), "close_time": float64(day + 22*3600)},
+			},
+			"pool_hours": []interface{}{
+				map[string]interface{}{"open_time": float64(day + 6*3600), "close_time": float64(day + 9*3600)},
+			},
+		}},
+	})
+	at := time.Unix(day+7*3600, 0)
+
+	tests := []struct {
+		facility  string
+		open      bool
+		closesAt  int64
+		minutesTo int64
+	}{
+		{models.FacilityPool, true, day + 9*3600, 120},
+		{models.FacilityBuilding, false, 0, 0},
+	}
+	for _, tt := range tests {
+		gyms, err := getGymsOpen(context.Background(), db, at, tt.facility)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.facility, err)
+		}
+		if !tt.open {
+			if len(gyms) != 0 {
+				t.Errorf("%s: got %d gyms, want none", tt.facility, len(gyms))
+			}
+			continue
+		}
+		if len(gyms) != 1 {
+			t.Fatalf("%s: got %d gyms, want 1", tt.facility, len(gyms))
+		}
+		status := gyms[0].Status
+		if !status.IsOpen || status.ClosesAt == nil || *status.ClosesAt != tt.closesAt {
+			t.Errorf("%s: status = %+v, want open until %d", tt.facility, status, tt.closesAt)
+		}
+		if status.MinutesUntilChange == nil || *status.MinutesUntilChange != tt.minutesTo {
+			t.Errorf("%s: minutes_until_change = %v, want %d", tt.facility, status.MinutesUntilChange, tt.minutesTo)
+		}
+	}
+}
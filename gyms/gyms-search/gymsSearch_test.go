@@ -0,0 +1,24 @@
+package gymssearch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+func TestGetGymByName(t *testing.T) {
+	db := store.NewMemory(map[string][]store.Document{
+		store.Gyms: {{"name": "RSF", "latitude": 37.8685, "longitude": -122.2625}},
+	})
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	gym, err := getGymByName(context.Background(), db, "RSF", at)
+	if err != nil || gym == nil || gym.Name != "RSF" {
+		t.Errorf("getGymByName(RSF) = %v, %v", gym, err)
+	}
+	if _, err := getGymByName(context.Background(), db, "Nope", at); err != store.ErrNotFound {
+		t.Errorf("getGymByName(Nope) error = %v, want store.ErrNotFound", err)
+	}
+}
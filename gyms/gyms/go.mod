@@ -1,18 +0,0 @@
-  
-module example.com/cloudfunction
-
-go 1.15
-
-require (
-	cloud.google.com/go v0.72.0
-	cloud.google.com/go/firestore v1.3.0
-	cloud.google.com/go/storage v1.12.0 // indirect
-	firebase.google.com/go v3.13.0+incompatible // indirect
-	github.com/google/go-cmp v0.5.3 // indirect
-	golang.org/x/net v0.0.0-20201110031124-69a78807bb2b // indirect
-	golang.org/x/oauth2 v0.0.0-20201109201403-9fd604954f58 // indirect
-	golang.org/x/sys v0.0.0-20201117222635-ba5294a509c7 // indirect
-	google.golang.org/api v0.35.0
-	google.golang.org/appengine v1.6.7 // indirect
-	google.golang.org/genproto v0.0.0-20201117123952-62d171c70ae1
-)
\ No newline at end of file
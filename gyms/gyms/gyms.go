@@ -6,93 +6,70 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
-	"cloud.google.com/go/firestore"
-	"google.golang.org/api/iterator"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-// type Timing struct {
-//     Open_Time int64 `json:"open_time"`
-//     Close_Time int64 `json:"close_time"`
-// }
+func GymEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/gyms", gymEndpoint, http.MethodGet)
+}
 
-// type Gym struct {
-//     Name string `json:"name"`
-//     Latitude float64 `json:"latitude"`
-//     Longitude float64 `json:"longitude"`
-//     Phone string `json:"phone"`
-//     Description string `json:"description"`
-//     Open_Close_Hours []Timing `json:"open_close_hours"`
-//     Track_Hours []Timing `json:"track_hours"`
-//     Pool_Hours []Timing `json:"pool_hours"`
-// }
-var client *firestore.Client
-var ctx context.Context
-var GymFields = [...]string{"name", "description", "latitude", "longitude", "address", "phone", "open_close_array", "track_hours", "pool_hours"}
+func gymEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-func GymEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
+	if !platform.Authenticate(w, r, platform.ScopeGymsRead) {
 		return
 	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
-	err := initFirestore(w)
+	db, err := platform.OpenStore(r.Context())
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
-		log.Printf("Firestore Init failed: %v", err)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
 		return
 	}
+	defer db.Close()
 	var output []byte
-	var allGyms []map[string]interface{}
-	allGyms, err = getAllGyms(w)
+	var allGyms []models.Gym
+	allGyms, err = getAllGyms(r.Context(), db, at)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("Get All Gyms failed: %v", err)
 		return
 	}
 	output, err = json.Marshal(allGyms)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("Couldn't convert gym to JSON: %v", err)
 		return
 	}
 	fmt.Fprint(w, string(output))
 }
 
-func getAllGyms(w http.ResponseWriter) ([]map[string]interface{}, error) {
-	/* Read Documents from Firestore*/
-	defer client.Close()
-	gyms := make([]map[string]interface{}, 0)
-	iter := client.Collection("Gyms").Documents(ctx)
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
+func getAllGyms(ctx context.Context, db store.Store, at time.Time) ([]models.Gym, error) {
+	docs, err := db.List(ctx, store.Gyms)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	gyms := make([]models.Gym, 0)
+	for _, docData := range docs {
+		gym, err := models.DecodeGym(docData)
 		if err != nil {
-			fmt.Println(err)
-			return nil, err
-		}
-		docData := doc.Data()
-		gym := make(map[string]interface{})
-		for _, element := range GymFields {
-			gym[element] = docData[element]
+			log.Printf("Skipping invalid gym: %v", err)
+			continue
 		}
+		gym.Evaluate(at, holidays)
 		gyms = append(gyms, gym)
 	}
 	return gyms, nil
@@ -0,0 +1,83 @@
+package librarieshours
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+// LibraryHoursEndpoint returns the opening hours of one library for each day
+// of a date range, this week by default, so apps can render an hours table.
+func LibraryHoursEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/libraries/{name}/hours", libraryHoursEndpoint, http.MethodGet)
+}
+
+func libraryHoursEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !platform.Authenticate(w, r, platform.ScopeLibrariesRead) {
+		return
+	}
+
+	name, ok := platform.NameParam(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := platform.DateRangeParams(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	db, err := platform.OpenStore(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	table, err := getLibraryHours(ctx, db, name, from, to)
+	if err == store.ErrNotFound {
+		platform.WriteError(w, http.StatusNotFound, platform.Error{
+			Code:    platform.CodeNotFound,
+			Param:   "name",
+			Message: "No library named '" + name + "'",
+		})
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("library hours GET failed: %v", err)
+		return
+	}
+	output, err := json.Marshal(table)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("library hours JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
+
+func getLibraryHours(ctx context.Context, db store.Store, name string, from, to time.Time) (*models.Timetable, error) {
+	doc, err := db.GetByName(ctx, store.Libraries, name)
+	if err != nil {
+		return nil, err
+	}
+	library, err := models.DecodeLibrary(doc)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	table := models.NewTimetable(library.Name, library.Schedule(holidays), from, to)
+	return &table, nil
+}
@@ -3,201 +3,91 @@ package librarieslocation
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"reflect"
-	"strconv"
+	"time"
 
-	"cloud.google.com/go/firestore"
-	"github.com/martinlindhe/unit"
-	"github.com/umahmood/haversine"
-	"google.golang.org/api/iterator"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-// type Timing struct {
-//     Open_Time int64 `json:"open_time"`
-//     Close_Time int64 `json:"close_time"`
-// }
-
-// type Library struct {
-// 	Name string `json:"name"`
-//     Latitude float64 `json:"latitude"`
-//     Longitude float64 `json:"longitude"`
-//     Description string `json:"description"`
-//     Address string `json:"address"`
-//     Open_Close_Hours []Timing `json:"open_close_hours"`
-// }
-
-var LibraryFields = [6]string{"name", "description", "latitude", "longitude", "address", "open_close_array"}
-var floatType = reflect.TypeOf(float64(0))
-var unitMap = map[string]unit.Length{
-	"ft": unit.Foot,
-	"yd": unit.Yard,
-	"mi": unit.Mile,
-	"m":  unit.Meter,
-	"km": unit.Kilometer,
+// Library is a library with its distance from the requested point in the
+// requested unit.
+type Library struct {
+	models.Library
+	Distance float64 `json:"distance"`
 }
-var client *firestore.Client
-var ctx context.Context
 
 func LibrariesLocationEndpoint(w http.ResponseWriter, r *http.Request) {
-	var radius float64
-	var longitude float64
-	var latitude float64
-	var units string
-	var err error
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
+	platform.Serve(w, r, "/v1/libraries/location", librariesLocationEndpoint, http.MethodGet)
+}
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
-		return
-	}
+func librariesLocationEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	radiusInput, ok := r.URL.Query()["radius"]
-	if ok {
-		if len(radiusInput[0]) >= 1 {
-			radius, err = strconv.ParseFloat(radiusInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'radius' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(radiusInput[0]) < 1 {
-			http.Error(w, "Url Param 'radius' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'radius' is missing", http.StatusBadRequest)
-		return
-	}
-	longitudeInput, ok := r.URL.Query()["longitude"]
-	if ok {
-		if len(longitudeInput[0]) >= 1 {
-			longitude, err = strconv.ParseFloat(longitudeInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'longitude' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(longitudeInput[0]) < 1 {
-			http.Error(w, "Url Param 'longitude' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'longitude' is missing", http.StatusBadRequest)
+	if !platform.Authenticate(w, r, platform.ScopeLibrariesRead) {
 		return
 	}
 
-	latitudeInput, ok := r.URL.Query()["latitude"]
-	if ok {
-		if len(latitudeInput[0]) >= 1 {
-			latitude, err = strconv.ParseFloat(latitudeInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'latitude' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(latitudeInput[0]) < 1 {
-			http.Error(w, "Url Param 'latitude' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'latitude' is missing", http.StatusBadRequest)
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
-	unitsInput, ok := r.URL.Query()["unit"]
-	if !ok || len(unitsInput) < 1 {
-		http.Error(w, "Url Param 'unit' is missing", http.StatusBadRequest)
+	area, ok := platform.AreaParams(w, r)
+	if !ok {
 		return
 	}
-	units = unitsInput[0]
-
-	var kilometers float64
-	kilometers, convertErr := convertToKilometers(radius, units)
-	if convertErr != nil {
-		http.Error(w, convertErr.Error(), http.StatusBadRequest)
+	page, ok := platform.PageParams(w, r)
+	if !ok {
 		return
 	}
-	fstoreErr := initFirestore(w)
+	ctx := r.Context()
+	db, fstoreErr := platform.OpenStore(ctx)
 	if fstoreErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
-		log.Printf("Firestore Init failed: %v", fstoreErr)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", fstoreErr)
 		return
 	}
-	libraries, libraryErr := locateLibraries(ctx, w, client, longitude, latitude, kilometers)
+	defer db.Close()
+	libraries, libraryErr := locateLibraries(ctx, db, at, area)
 	if libraryErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("libraries location GET failed: %v", libraryErr)
 		return
 	}
-	output, jsonErr := json.Marshal(&libraries)
+	start, end := page.Bounds(len(libraries))
+	output, jsonErr := json.Marshal(libraries[start:end])
 	if jsonErr != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("libraries JSON conversion failed: %v", jsonErr)
 		return
 	}
 	fmt.Fprint(w, string(output))
 }
 
-func locateLibraries(ctx context.Context, w http.ResponseWriter, client *firestore.Client, longitude float64, latitude float64, radius float64) ([]map[string]interface{}, error) {
-	defer client.Close()
-	libraries := make([]map[string]interface{}, 0)
-	iter := client.Collection("Libraries").Documents(ctx)
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
+// locateLibraries returns the libraries in area, nearest first.
+func locateLibraries(ctx context.Context, db store.Store, at time.Time, area platform.Area) ([]Library, error) {
+	libraries := make([]Library, 0)
+	docs, err := area.Find(ctx, db, store.Libraries)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, docData := range docs {
+		library, err := models.DecodeLibrary(docData)
 		if err != nil {
-			return nil, err
-		}
-		docData := doc.Data()
-		docLat, latErr := getFloat(docData["latitude"])
-		docLon, lonErr := getFloat(docData["longitude"])
-		if latErr != nil || lonErr != nil {
+			log.Printf("Skipping invalid library: %v", err)
 			continue
 		}
-		_, km := haversine.Distance(haversine.Coord{Lat: latitude, Lon: longitude},
-			haversine.Coord{Lat: docLat, Lon: docLon})
-		if km < radius {
-			library := make(map[string]interface{})
-			for _, element := range LibraryFields {
-				library[element] = docData[element]
-			}
-			libraries = append(libraries, library)
-		}
+		library.Evaluate(at, holidays)
+		distance := area.Distance(library.Latitude, library.Longitude)
+		libraries = append(libraries, Library{Library: library, Distance: distance})
 	}
 	return libraries, nil
 }
-
-func convertToKilometers(value float64, units string) (float64, error) {
-	unitConvert, valid := unitMap[units]
-	if valid {
-		return (unit.Length(value) * unitConvert).Kilometers(), nil
-	} else {
-		return 0, errors.New("URL Param 'unit' is incorrect")
-	}
-}
-
-func getFloat(unk interface{}) (float64, error) {
-	v := reflect.ValueOf(unk)
-	v = reflect.Indirect(v)
-	if !v.Type().ConvertibleTo(floatType) {
-		return 0, fmt.Errorf("cannot convert %v to float64", v.Type())
-	}
-	fv := v.Convert(floatType)
-	return fv.Float(), nil
-}
@@ -1,11 +0,0 @@
-module example.com/cloudfunction
-
-go 1.15
-
-require (
-	cloud.google.com/go v0.66.0
-	cloud.google.com/go/firestore v1.3.0
-	cloud.google.com/go/storage v1.12.0 // indirect
-	google.golang.org/api v0.35.0
-	google.golang.org/genproto v0.0.0-20200921151605-7abf4a1a14d5
-)
@@ -0,0 +1,174 @@
+package nearbyopen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+// Place is an open facility of any category, with its distance from the
+// requested point in the requested unit.
+type Place struct {
+	models.Place
+	Category string  `json:"category"`
+	Distance float64 `json:"distance"`
+	models.Status
+}
+
+// category is a kind of facility the endpoint searches.
+type category struct {
+	collection string
+	scope      string
+	decode     func(store.Document) (models.Place, models.Hours, error)
+}
+
+// categoryNames lists the categories in the order they are searched.
+var categoryNames = []string{"gyms", "libraries", "dining", "resources"}
+
+var categories = map[string]category{
+	"gyms": {store.Gyms, platform.ScopeGymsRead, func(doc store.Document) (models.Place, models.Hours, error) {
+		gym, err := models.DecodeGym(doc)
+		return gym.Place, gym.Hours, err
+	}},
+	"libraries": {store.Libraries, platform.ScopeLibrariesRead, func(doc store.Document) (models.Place, models.Hours, error) {
+		library, err := models.DecodeLibrary(doc)
+		return library.Place, library.Hours, err
+	}},
+	"dining": {store.DiningHalls, platform.ScopeDiningRead, func(doc store.Document) (models.Place, models.Hours, error) {
+		dining, err := models.DecodeDiningHall(doc)
+		return dining.Place, dining.Hours, err
+	}},
+	"resources": {store.Resources, platform.ScopeResourcesRead, func(doc store.Document) (models.Place, models.Hours, error) {
+		resource, err := models.DecodeResource(doc)
+		return resource.Place, resource.Hours, err
+	}},
+}
+
+// NearbyOpenEndpoint lists the gyms, libraries, dining halls and resources
+// within radius of a point that are open at the time param, nearest first.
+// The categories param, e.g. "gyms,libraries", narrows the search; by
+// default it covers every category the caller has the read scope for.
+func NearbyOpenEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/nearby/open", nearbyOpenEndpoint, http.MethodGet)
+}
+
+func nearbyOpenEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateClaims(w, r)
+	if !ok {
+		return
+	}
+
+	area, ok := platform.AreaParams(w, r)
+	if !ok {
+		return
+	}
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
+		return
+	}
+	names, ok := categoriesParam(w, r, claims)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	db, err := platform.OpenStore(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	places, err := openNearby(ctx, db, area, at, names)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("nearby open GET failed: %v", err)
+		return
+	}
+	output, err := json.Marshal(places)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("nearby open JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
+
+// categoriesParam returns the categories to search. Asking for a category
+// without its scope is refused with 403.
+func categoriesParam(w http.ResponseWriter, r *http.Request, claims *platform.Claims) ([]string, bool) {
+	param := r.URL.Query().Get("categories")
+	if param == "" {
+		var names, scopes []string
+		for _, name := range categoryNames {
+			scopes = append(scopes, categories[name].scope)
+			if claims.HasScope(categories[name].scope) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			platform.InsufficientScope(w, scopes...)
+			return nil, false
+		}
+		return names, true
+	}
+	var names []string
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		c, ok := categories[name]
+		if !ok {
+			platform.InvalidParam(w, "categories")
+			return nil, false
+		}
+		if !claims.HasScope(c.scope) {
+			platform.InsufficientScope(w, c.scope)
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
+
+func openNearby(ctx context.Context, db store.Store, area platform.Area, at time.Time, names []string) ([]Place, error) {
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	places := make([]Place, 0)
+	for _, name := range names {
+		c := categories[name]
+		docs, err := area.Find(ctx, db, c.collection)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			place, hours, err := c.decode(doc)
+			if err != nil {
+				log.Printf("Skipping invalid document in %s: %v", c.collection, err)
+				continue
+			}
+			status := models.StatusAt(hours.Schedule(holidays), at)
+			if !status.IsOpen {
+				continue
+			}
+			places = append(places, Place{
+				Place:    place,
+				Category: name,
+				Distance: area.Distance(place.Latitude, place.Longitude),
+				Status:   status,
+			})
+		}
+	}
+	sort.SliceStable(places, func(i, j int) bool { return places[i].Distance < places[j].Distance })
+	return places, nil
+}
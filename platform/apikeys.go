@@ -0,0 +1,145 @@
+package platform
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// TokenTypeAPIKey marks the claims of a request authenticated with an API key.
+const TokenTypeAPIKey = "api_key"
+
+// APIKeyHeader carries an API key. Keys are also accepted as bearer tokens.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix starts every API key, which keeps them apart from JWTs. Keys
+// look like octo_<id>_<secret>.
+const apiKeyPrefix = "octo_"
+
+// lastUsedInterval bounds how often a key's last-used time is written.
+const lastUsedInterval = time.Minute
+
+// Reasons an API key is rejected.
+var (
+	ErrInvalidAPIKey = invalidToken("unknown API key")
+	ErrRevokedAPIKey = invalidToken("API key has been revoked")
+)
+
+// NewAPIKey creates an API key for uid granting scopes and returns it. The
+// key itself is only available here; the store keeps a hash of it.
+func NewAPIKey(ctx context.Context, db users.Store, uid, name string, scopes []string) (string, *users.APIKey, error) {
+	apiKey, key, err := GenerateAPIKey(uid, name, scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := db.CreateAPIKey(ctx, key); err != nil {
+		return "", nil, err
+	}
+	return apiKey, key, nil
+}
+
+// GenerateAPIKey returns a new API key for uid granting scopes, and the
+// document to store for it, without storing it.
+func GenerateAPIKey(uid, name string, scopes []string) (string, *users.APIKey, error) {
+	id, err := randomString(9)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomString(24)
+	if err != nil {
+		return "", nil, err
+	}
+	key := &users.APIKey{
+		ID:        id,
+		UID:       uid,
+		Name:      name,
+		Hash:      hashAPIKeySecret(secret),
+		Scopes:    scopes,
+		CreatedAt: time.Now().Unix(),
+	}
+	return apiKeyPrefix + id + "_" + secret, key, nil
+}
+
+// ValidateAPIKey checks an API key and returns claims for its owner. Rejected
+// keys produce an error for which IsTokenError is true; keys of blocked users
+// produce ErrAccountBlocked.
+func ValidateAPIKey(ctx context.Context, db users.Store, apiKey string) (*Claims, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(apiKey, apiKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(apiKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := db.GetAPIKey(ctx, id)
+	if err == users.ErrNotFound {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKeySecret(secret))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.Revoked() {
+		return nil, ErrRevokedAPIKey
+	}
+	user, err := db.Get(ctx, key.UID)
+	if err == users.ErrNotFound {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Blocked {
+		return nil, ErrAccountBlocked
+	}
+	now := time.Now()
+	if now.Sub(time.Unix(key.LastUsedAt, 0)) >= lastUsedInterval {
+		if err := db.TouchAPIKey(ctx, key.ID, now.Unix()); err != nil {
+			return nil, err
+		}
+		err := db.Update(ctx, key.UID, func(user *users.User) error {
+			user.LastActiveAt = now.Unix()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	scopes := IntersectScopes(key.Scopes, AllowedScopes(user))
+	claims := &Claims{UID: key.UID, Type: TokenTypeAPIKey, Scope: strings.Join(scopes, " ")}
+	claims.Id = key.ID
+	return claims, nil
+}
+
+// requestAPIKey returns the API key sent with the request, if any.
+func requestAPIKey(r *http.Request) (string, bool) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key, true
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") && strings.HasPrefix(strings.TrimSpace(token), apiKeyPrefix) {
+		return strings.TrimSpace(token), true
+	}
+	return "", false
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes encoded without padding or underscores.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(base64.RawURLEncoding.EncodeToString(b), "_", "-"), nil
+}
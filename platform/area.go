@@ -0,0 +1,119 @@
+package platform
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/martinlindhe/unit"
+	"github.com/umahmood/haversine"
+
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+// Units maps the unit param to the lengths it names.
+var Units = map[string]unit.Length{
+	"ft": unit.Foot,
+	"yd": unit.Yard,
+	"mi": unit.Mile,
+	"m":  unit.Meter,
+	"km": unit.Kilometer,
+}
+
+// Area is a circle around a point, with its radius in Unit. An Area with
+// Nearest set holds the Nearest places closest to the point instead, within
+// Radius if it is set too.
+type Area struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+	Unit      string
+	Nearest   int
+}
+
+// Find returns the documents of the collection in the area, nearest first.
+func (a Area) Find(ctx context.Context, db store.Store, collection string) ([]store.Document, error) {
+	radiusKm := (unit.Length(a.Radius) * Units[a.Unit]).Kilometers()
+	if a.Nearest == 0 {
+		return db.Near(ctx, collection, a.Latitude, a.Longitude, radiusKm)
+	}
+	docs, err := db.Nearest(ctx, collection, a.Latitude, a.Longitude, a.Nearest)
+	// AreaParams only leaves Radius zero when it wasn't given.
+	if err != nil || a.Radius == 0 {
+		return docs, err
+	}
+	for i, doc := range docs {
+		// Documents come nearest first, so the rest are outside too.
+		latitude, longitude, _ := store.Location(doc)
+		if !a.Contains(latitude, longitude) {
+			return docs[:i], nil
+		}
+	}
+	return docs, nil
+}
+
+// Distance returns the great-circle distance from the area's center to a
+// point, in the area's unit.
+func (a Area) Distance(latitude, longitude float64) float64 {
+	_, km := haversine.Distance(haversine.Coord{Lat: a.Latitude, Lon: a.Longitude},
+		haversine.Coord{Lat: latitude, Lon: longitude})
+	return float64(unit.Length(km) * unit.Kilometer / Units[a.Unit])
+}
+
+// Contains reports whether a point lies within the area.
+func (a Area) Contains(latitude, longitude float64) bool {
+	return a.Distance(latitude, longitude) <= a.Radius
+}
+
+// AreaParams reads the latitude, longitude, radius and unit query params,
+// and nearest, the number of closest places to return, which makes radius
+// optional. It replies 400 and returns false when one is missing, can't be
+// parsed or is out of range: a radius that isn't positive, even alongside
+// nearest, or a latitude or longitude off the globe.
+func AreaParams(w http.ResponseWriter, r *http.Request) (Area, bool) {
+	var area Area
+	if value := r.URL.Query().Get("nearest"); value != "" {
+		nearest, err := strconv.Atoi(value)
+		if err != nil || nearest < 1 || nearest > MaxLimit {
+			InvalidParam(w, "nearest")
+			return Area{}, false
+		}
+		area.Nearest = nearest
+	}
+	for _, param := range []struct {
+		name     string
+		value    *float64
+		min, max float64
+	}{
+		{"radius", &area.Radius, math.SmallestNonzeroFloat64, math.MaxFloat64},
+		{"longitude", &area.Longitude, -180, 180},
+		{"latitude", &area.Latitude, -90, 90},
+	} {
+		values, ok := r.URL.Query()[param.name]
+		if !ok && param.name == "radius" && area.Nearest > 0 {
+			continue
+		}
+		if !ok {
+			MissingParam(w, param.name)
+			return Area{}, false
+		}
+		f, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || math.IsNaN(f) || f < param.min || f > param.max {
+			InvalidParam(w, param.name)
+			return Area{}, false
+		}
+		*param.value = f
+	}
+	units, ok := r.URL.Query()["unit"]
+	if !ok || len(units) < 1 {
+		MissingParam(w, "unit")
+		return Area{}, false
+	}
+	if _, ok := Units[units[0]]; !ok {
+		InvalidParam(w, "unit")
+		return Area{}, false
+	}
+	area.Unit = units[0]
+	return area, true
+}
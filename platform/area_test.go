@@ -0,0 +1,65 @@
+package platform
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// decodeError returns the error envelope of a recorded response.
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) Error {
+	t.Helper()
+	var body errorEnvelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding error body %q: %v", rec.Body.String(), err)
+	}
+	return body.Error
+}
+
+func TestAreaParams(t *testing.T) {
+	tests := []struct {
+		query     string
+		wantCode  string
+		wantParam string
+	}{
+		{"latitude=37.87&longitude=-122.26&radius=2&unit=km", "", ""},
+		{"latitude=-90&longitude=180&radius=0.1&unit=mi", "", ""},
+		{"latitude=37.87&longitude=-122.26&nearest=3&unit=km", "", ""},
+		{"latitude=37.87&longitude=-122.26&unit=km", CodeMissingParam, "radius"},
+		{"longitude=-122.26&radius=2&unit=km", CodeMissingParam, "latitude"},
+		{"latitude=37.87&longitude=-122.26&radius=2", CodeMissingParam, "unit"},
+		{"latitude=37.87&longitude=-122.26&radius=2&unit=parsec", CodeInvalidParam, "unit"},
+		{"latitude=37.87&longitude=-122.26&radius=-1&unit=km", CodeInvalidParam, "radius"},
+		{"latitude=37.87&longitude=-122.26&radius=0&unit=km", CodeInvalidParam, "radius"},
+		{"latitude=37.87&longitude=-122.26&nearest=3&radius=0&unit=km", CodeInvalidParam, "radius"},
+		{"latitude=37.87&longitude=-122.26&radius=NaN&unit=km", CodeInvalidParam, "radius"},
+		{"latitude=37.87&longitude=-122.26&radius=Inf&unit=km", CodeInvalidParam, "radius"},
+		{"latitude=90.5&longitude=-122.26&radius=2&unit=km", CodeInvalidParam, "latitude"},
+		{"latitude=-91&longitude=-122.26&radius=2&unit=km", CodeInvalidParam, "latitude"},
+		{"latitude=37.87&longitude=180.1&radius=2&unit=km", CodeInvalidParam, "longitude"},
+		{"latitude=37.87&longitude=-Inf&radius=2&unit=km", CodeInvalidParam, "longitude"},
+		{"latitude=nan&longitude=-122.26&radius=2&unit=km", CodeInvalidParam, "latitude"},
+		{"latitude=37.87&longitude=-122.26&nearest=0&unit=km", CodeInvalidParam, "nearest"},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		_, ok := AreaParams(rec, httptest.NewRequest(http.MethodGet, "/?"+test.query, nil))
+		if test.wantCode == "" {
+			if !ok {
+				t.Errorf("%s: rejected: %s", test.query, rec.Body)
+			}
+			continue
+		}
+		if ok {
+			t.Errorf("%s: accepted, want %s on %s", test.query, test.wantCode, test.wantParam)
+			continue
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", test.query, rec.Code)
+		}
+		if e := decodeError(t, rec); e.Code != test.wantCode || e.Param != test.wantParam {
+			t.Errorf("%s: error %s on %s, want %s on %s", test.query, e.Code, e.Param, test.wantCode, test.wantParam)
+		}
+	}
+}
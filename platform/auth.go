@@ -0,0 +1,158 @@
+package platform
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// InvalidTokenMessage is returned to callers whose access token is rejected.
+const InvalidTokenMessage = "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint."
+
+// BlockedMessage is returned to users whose account has been blocked.
+const BlockedMessage = "Your account has been blocked. If you believe something went wrong, please contact octo.api@asuc.org for details."
+
+// Reasons a request fails authentication.
+var (
+	ErrMissingToken   = errors.New("access token is missing")
+	ErrExpiredToken   = errors.New("token has expired")
+	ErrInvalidToken   = errors.New("token is invalid")
+	ErrAccountBlocked = errors.New("account is blocked")
+)
+
+// ValidateAccessToken checks the API key or bearer access token sent with the
+// request and returns its claims. Rejected credentials produce an error for
+// which IsTokenError is true; credentials of blocked users produce
+// ErrAccountBlocked.
+func ValidateAccessToken(r *http.Request) (*Claims, error) {
+	if apiKey, ok := requestAPIKey(r); ok {
+		db, err := OpenUsers(r.Context())
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		return ValidateAPIKey(r.Context(), db, apiKey)
+	}
+	token, err := bearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := ParseToken(r.Context(), token, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBlocked(r.Context(), claims.UID); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", ErrMissingToken
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", ErrInvalidScheme
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrInvalidScheme
+	}
+	return token, nil
+}
+
+// Authenticate validates the request's credentials and replies with 401 when
+// they are rejected, 403 when they belong to a blocked user or lack one of
+// the required scopes, or 429 when the caller is over their rate limit. It
+// returns false when the handler should stop.
+func Authenticate(w http.ResponseWriter, r *http.Request, scopes ...string) bool {
+	_, ok := AuthenticateClaims(w, r, scopes...)
+	return ok
+}
+
+// AuthenticateClaims is Authenticate for handlers that need to know who is
+// calling. It returns the claims of the accepted credentials.
+func AuthenticateClaims(w http.ResponseWriter, r *http.Request, scopes ...string) (*Claims, bool) {
+	claims, err := ValidateAccessToken(r)
+	if err == nil {
+		meterCaller(r, claims)
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				InsufficientScope(w, scopes...)
+				return nil, false
+			}
+		}
+		if !RateLimit(w, r, claims, scopes...) {
+			return nil, false
+		}
+		return claims, true
+	}
+	if err == ErrAccountBlocked {
+		Forbidden(w)
+		return nil, false
+	}
+	if !IsTokenError(err) {
+		InternalError(w)
+		log.Printf("access token validation failed: %v", err)
+		return nil, false
+	}
+	Unauthorized(w, err)
+	return nil, false
+}
+
+// AuthenticateUser is AuthenticateClaims for endpoints that manage the
+// caller's account. It only accepts access tokens from the login endpoint, so
+// neither a leaked API key nor a token issued to an OAuth client can mint
+// more credentials.
+func AuthenticateUser(w http.ResponseWriter, r *http.Request) (*Claims, bool) {
+	claims, ok := AuthenticateClaims(w, r)
+	if !ok {
+		return nil, false
+	}
+	if claims.Type != TokenTypeAccess || claims.ClientID != "" {
+		WriteError(w, http.StatusForbidden, Error{
+			Code:    CodeForbidden,
+			Message: "Your account can only be managed with an access token from the login endpoint.",
+		})
+		return nil, false
+	}
+	return claims, true
+}
+
+// Unauthorized replies with 401 and a Bearer challenge describing err.
+func Unauthorized(w http.ResponseWriter, err error) {
+	code := CodeInvalidToken
+	challenge := `Bearer realm="octoapi"`
+	// Requests without credentials get a bare challenge, per RFC 6750.
+	if err == ErrMissingToken {
+		code = CodeMissingToken
+	} else {
+		if errors.Is(err, ErrExpiredToken) {
+			code = CodeExpiredToken
+		}
+		challenge += `, error="invalid_token", error_description="` + err.Error() + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	WriteError(w, http.StatusUnauthorized, Error{
+		Code:    code,
+		Message: capitalize(err.Error()) + ". " + InvalidTokenMessage,
+	})
+}
+
+// Forbidden replies with 403 to a blocked account.
+func Forbidden(w http.ResponseWriter) {
+	WriteError(w, http.StatusForbidden, Error{
+		Code:    CodeAccountBlocked,
+		Message: BlockedMessage,
+	})
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
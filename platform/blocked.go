@@ -0,0 +1,64 @@
+package platform
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// blockedTTL bounds how long an instance trusts what it last read of a
+// user's blocked flag, and so how long a blocked user's access tokens keep
+// working on instances that didn't do the blocking.
+const blockedTTL = time.Minute
+
+// blockedCache remembers which users are blocked, so access tokens, which
+// carry no state of their own, can be refused without reading the user on
+// every request.
+var blockedCache = struct {
+	mu      sync.Mutex
+	entries map[string]blockedEntry
+}{entries: make(map[string]blockedEntry)}
+
+type blockedEntry struct {
+	blocked bool
+	read    time.Time
+}
+
+// checkBlocked returns ErrAccountBlocked if the user is blocked, reading the
+// users collection at most once per blockedTTL per user. A user that no
+// longer exists gets ErrInvalidToken.
+func checkBlocked(ctx context.Context, uid string) error {
+	blockedCache.mu.Lock()
+	entry, ok := blockedCache.entries[uid]
+	blockedCache.mu.Unlock()
+	if !ok || time.Since(entry.read) >= blockedTTL {
+		db, err := OpenUsers(ctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		user, err := db.Get(ctx, uid)
+		if err == users.ErrNotFound {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+		entry = blockedEntry{blocked: user.Blocked, read: time.Now()}
+		RememberBlocked(uid, user.Blocked)
+	}
+	if entry.blocked {
+		return ErrAccountBlocked
+	}
+	return nil
+}
+
+// RememberBlocked records that the user was just blocked or unblocked, so
+// this instance applies it at once instead of after blockedTTL.
+func RememberBlocked(uid string, blocked bool) {
+	blockedCache.mu.Lock()
+	defer blockedCache.mu.Unlock()
+	blockedCache.entries[uid] = blockedEntry{blocked: blocked, read: time.Now()}
+}
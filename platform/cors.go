@@ -0,0 +1,179 @@
+package platform
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CORSOriginsEnv lists, comma separated, the origins browsers may call the
+// API from. "*" allows any origin and is the default.
+const CORSOriginsEnv = "OCTOAPI_CORS_ORIGINS"
+
+// CORSAdminOriginsEnv lists the origins allowed to call the admin endpoints,
+// which also accept credentials from them. It defaults to CORSOriginsEnv, but
+// as credentialed requests are never allowed from "*", a browser dashboard
+// needs its origin listed here by name.
+const CORSAdminOriginsEnv = "OCTOAPI_CORS_ADMIN_ORIGINS"
+
+// Request headers cross-origin callers may send, and response headers they
+// may read.
+const (
+	corsAllowHeaders  = "Authorization, Content-Type, X-API-Key"
+	corsExposeHeaders = "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset"
+)
+
+// CORSPolicy says which cross-origin requests an endpoint accepts.
+type CORSPolicy struct {
+	// Origins lists the allowed origins; "*" allows any.
+	Origins []string
+	// Methods lists the methods the endpoint supports. HEAD is allowed
+	// along with GET, and OPTIONS preflights are always answered.
+	Methods []string
+	// Credentials lets browsers send cookies and HTTP auth. It only applies
+	// to origins listed by name, never to "*".
+	Credentials bool
+	// MaxAge is how long browsers may cache a preflight, an hour if zero.
+	MaxAge time.Duration
+}
+
+// ParseOrigins splits a comma-separated origin list such as the value of
+// OCTOAPI_CORS_ORIGINS, defaulting to "*" when it is empty.
+func ParseOrigins(s string) []string {
+	var origins []string
+	for _, origin := range strings.Split(s, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		return []string{"*"}
+	}
+	return origins
+}
+
+// EndpointPolicy returns the CORS policy of the endpoint at path supporting
+// methods: the origins in OCTOAPI_CORS_ORIGINS, or for the /v1/admin/
+// endpoints those in OCTOAPI_CORS_ADMIN_ORIGINS with credentials, so the
+// dashboard can be allowed there without opening them to every public API
+// caller.
+func EndpointPolicy(path string, methods ...string) CORSPolicy {
+	origins := ParseOrigins(os.Getenv(CORSOriginsEnv))
+	if !strings.HasPrefix(path, "/v1/admin/") {
+		return CORSPolicy{Origins: origins, Methods: methods}
+	}
+	if env := os.Getenv(CORSAdminOriginsEnv); env != "" {
+		origins = ParseOrigins(env)
+	}
+	WarnAdminCORS()
+	return CORSPolicy{Origins: origins, Methods: methods, Credentials: true}
+}
+
+var adminCORSWarning sync.Once
+
+// WarnAdminCORS logs, once per process, when no origin is allowed to call
+// the admin endpoints from a browser, which otherwise fails without a trace
+// on the server.
+func WarnAdminCORS() {
+	adminCORSWarning.Do(func() {
+		origins := ParseOrigins(os.Getenv(CORSOriginsEnv))
+		if env := os.Getenv(CORSAdminOriginsEnv); env != "" {
+			origins = ParseOrigins(env)
+		}
+		for _, origin := range origins {
+			if origin != "*" {
+				return
+			}
+		}
+		log.Printf("warning: %s lists no origin by name, so browsers can't call the /v1/admin/ endpoints; set it to the admin dashboard's origin", CORSAdminOriginsEnv)
+	})
+}
+
+// Serve runs an endpoint's handler with the CORS policy for its path and
+// methods, metering it under path. Every exported endpoint goes through it,
+// so CORS and usage work the same whether it is deployed as its own Cloud
+// Function or mounted by cmd/octoapi.
+func Serve(w http.ResponseWriter, r *http.Request, path string, handler http.HandlerFunc, methods ...string) {
+	Meter(path, CORS(EndpointPolicy(path, methods...), handler))(w, r)
+}
+
+// CORS wraps an endpoint with policy. It answers preflight requests itself,
+// replies 405 to methods the endpoint doesn't support and sets the CORS
+// headers on every other response.
+func CORS(policy CORSPolicy, handler http.HandlerFunc) http.HandlerFunc {
+	methods := strings.Join(policy.Methods, ", ")
+	maxAge := policy.MaxAge
+	if maxAge == 0 {
+		maxAge = time.Hour
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		origin := r.Header.Get("Origin")
+		allowOrigin, varies := policy.allowOrigin(origin)
+		if varies {
+			header.Add("Vary", "Origin")
+		}
+		if allowOrigin != "" {
+			header.Set("Access-Control-Allow-Origin", allowOrigin)
+			if policy.Credentials && allowOrigin != "*" {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if r.Method == http.MethodOptions {
+			if allowOrigin != "" && policy.supports(r.Header.Get("Access-Control-Request-Method")) {
+				header.Set("Access-Control-Allow-Methods", methods)
+				header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+			}
+			header.Set("Allow", methods+", OPTIONS")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !policy.supports(r.Method) {
+			MethodNotAllowed(w, policy.Methods...)
+			return
+		}
+		if allowOrigin != "" {
+			header.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		}
+		handler(w, r)
+	}
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request
+// from origin, empty when it isn't allowed, and whether the answer depends
+// on the origin so caches must vary on it.
+// A credentialed policy never treats "*" as matching every origin: browsers
+// refuse the wildcard there, and echoing any origin would let every site in.
+func (p CORSPolicy) allowOrigin(origin string) (string, bool) {
+	if !p.Credentials && containsOrigin(p.Origins, "*") {
+		return "*", false
+	}
+	if origin != "" && containsOrigin(p.Origins, origin) {
+		return origin, true
+	}
+	return "", true
+}
+
+func containsOrigin(origins []string, origin string) bool {
+	for _, o := range origins {
+		if strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p CORSPolicy) supports(method string) bool {
+	for _, m := range p.Methods {
+		if m == method || (m == http.MethodGet && method == http.MethodHead) {
+			return true
+		}
+	}
+	return false
+}
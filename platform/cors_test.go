@@ -0,0 +1,100 @@
+package platform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	public := CORSPolicy{Origins: []string{"*"}, Methods: []string{http.MethodGet}}
+	listed := CORSPolicy{Origins: []string{"https://app.example.edu"}, Methods: []string{http.MethodGet, http.MethodPost}}
+	credentialed := CORSPolicy{Origins: []string{"*", "https://admin.example.edu"}, Methods: []string{http.MethodGet}, Credentials: true}
+	tests := []struct {
+		name          string
+		policy        CORSPolicy
+		method        string
+		origin        string
+		requestMethod string // Access-Control-Request-Method of a preflight
+		status        int
+		allowOrigin   string
+		credentials   string
+		allowMethods  string
+		vary          bool
+	}{
+		{"any origin", public, http.MethodGet, "https://x.example", "", http.StatusOK, "*", "", "", false},
+		{"no origin", public, http.MethodGet, "", "", http.StatusOK, "*", "", "", false},
+		{"HEAD along with GET", public, http.MethodHead, "https://x.example", "", http.StatusOK, "*", "", "", false},
+		{"unsupported method", public, http.MethodDelete, "https://x.example", "", http.StatusMethodNotAllowed, "*", "", "", false},
+		{"preflight", public, http.MethodOptions, "https://x.example", http.MethodGet, http.StatusNoContent, "*", "", "GET", false},
+		{"preflight for an unsupported method", public, http.MethodOptions, "https://x.example", http.MethodPut, http.StatusNoContent, "*", "", "", false},
+		{"listed origin", listed, http.MethodPost, "https://app.example.edu", "", http.StatusOK, "https://app.example.edu", "", "", true},
+		{"listed origin, other case", listed, http.MethodGet, "HTTPS://APP.example.edu", "", http.StatusOK, "HTTPS://APP.example.edu", "", "", true},
+		{"unlisted origin", listed, http.MethodGet, "https://evil.example", "", http.StatusOK, "", "", "", true},
+		{"preflight from an unlisted origin", listed, http.MethodOptions, "https://evil.example", http.MethodGet, http.StatusNoContent, "", "", "", true},
+		{"credentials for a listed origin", credentialed, http.MethodGet, "https://admin.example.edu", "", http.StatusOK, "https://admin.example.edu", "true", "", true},
+		// "*" never lets a credentialed policy answer other origins.
+		{"credentials ignore the wildcard", credentialed, http.MethodGet, "https://evil.example", "", http.StatusOK, "", "", "", true},
+	}
+	for _, test := range tests {
+		called := false
+		handler := CORS(test.policy, func(w http.ResponseWriter, r *http.Request) { called = true })
+		r := httptest.NewRequest(test.method, "/v1/gyms", nil)
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+		if test.requestMethod != "" {
+			r.Header.Set("Access-Control-Request-Method", test.requestMethod)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+
+		header := rec.Header()
+		if rec.Code != test.status {
+			t.Errorf("%s: status %d, want %d", test.name, rec.Code, test.status)
+		}
+		if want := test.status == http.StatusOK; called != want {
+			t.Errorf("%s: handler called = %v, want %v", test.name, called, want)
+		}
+		if got := header.Get("Access-Control-Allow-Origin"); got != test.allowOrigin {
+			t.Errorf("%s: Allow-Origin = %q, want %q", test.name, got, test.allowOrigin)
+		}
+		if got := header.Get("Access-Control-Allow-Credentials"); got != test.credentials {
+			t.Errorf("%s: Allow-Credentials = %q, want %q", test.name, got, test.credentials)
+		}
+		if got := header.Get("Access-Control-Allow-Methods"); got != test.allowMethods {
+			t.Errorf("%s: Allow-Methods = %q, want %q", test.name, got, test.allowMethods)
+		}
+		if got := header.Get("Vary") == "Origin"; got != test.vary {
+			t.Errorf("%s: varies on Origin = %v, want %v", test.name, got, test.vary)
+		}
+	}
+}
+
+func TestEndpointPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		origins      string
+		adminOrigins string
+		path         string
+		want         CORSPolicy
+	}{
+		{"default", "", "", "/v1/gyms", CORSPolicy{Origins: []string{"*"}, Methods: []string{"GET"}}},
+		{"listed", "https://a.example, https://b.example", "", "/v1/gyms",
+			CORSPolicy{Origins: []string{"https://a.example", "https://b.example"}, Methods: []string{"GET"}}},
+		{"admin defaults to the public origins", "https://a.example", "", "/v1/admin/users",
+			CORSPolicy{Origins: []string{"https://a.example"}, Methods: []string{"GET"}, Credentials: true}},
+		{"admin origins", "*", "https://admin.example", "/v1/admin/users",
+			CORSPolicy{Origins: []string{"https://admin.example"}, Methods: []string{"GET"}, Credentials: true}},
+		{"admin origins only apply to admin endpoints", "*", "https://admin.example", "/v1/gyms",
+			CORSPolicy{Origins: []string{"*"}, Methods: []string{"GET"}}},
+	}
+	for _, test := range tests {
+		t.Setenv(CORSOriginsEnv, test.origins)
+		t.Setenv(CORSAdminOriginsEnv, test.adminOrigins)
+		if got := EndpointPolicy(test.path, http.MethodGet); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: EndpointPolicy = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/asuc-octo/octoapi/platform/store"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// StoreFileEnv names a JSON file to serve the facility collections from
+// instead of Firestore. See store.LoadFile for the format.
+const StoreFileEnv = "OCTOAPI_STORE_FILE"
+
+// UsersFileEnv names a JSON file to seed an in-memory users collection from
+// instead of Firestore. See users.LoadFile for the format.
+const UsersFileEnv = "OCTOAPI_USERS_FILE"
+
+var memoryStore struct {
+	once  sync.Once
+	store *store.Memory
+	err   error
+}
+
+var memoryUsers struct {
+	once  sync.Once
+	store *users.Memory
+	err   error
+}
+
+// OpenStore returns the store holding the facility collections. Callers must
+// Close it when done.
+func OpenStore(ctx context.Context) (store.Store, error) {
+	if path := os.Getenv(StoreFileEnv); path != "" {
+		memoryStore.once.Do(func() {
+			memoryStore.store, memoryStore.err = store.LoadFile(path)
+		})
+		if memoryStore.err != nil {
+			return nil, memoryStore.err
+		}
+		return memoryStore.store, nil
+	}
+	client, err := NewFirestoreClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.NewFirestore(client), nil
+}
+
+// OpenUsers returns the store holding the API users. Callers must Close it
+// when done.
+func OpenUsers(ctx context.Context) (users.Store, error) {
+	if path := os.Getenv(UsersFileEnv); path != "" {
+		memoryUsers.once.Do(func() {
+			memoryUsers.store, memoryUsers.err = users.LoadFile(path)
+		})
+		if memoryUsers.err != nil {
+			return nil, memoryUsers.err
+		}
+		return memoryUsers.store, nil
+	}
+	client, err := NewUsersFirestoreClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return users.NewFirestore(client), nil
+}
@@ -0,0 +1,93 @@
+package platform
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Machine-readable codes returned in the error envelope.
+const (
+	CodeInvalidParam   = "invalid_param"
+	CodeMissingParam   = "missing_param"
+	CodeInvalidBody    = "invalid_body"
+	CodeInvalidRequest = "invalid_request"
+	CodeInvalidToken   = "invalid_token"
+	CodeMissingToken   = "missing_token"
+	CodeExpiredToken   = "expired_token"
+	CodeAccountBlocked = "account_blocked"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeMethod         = "method_not_allowed"
+	CodeInternal       = "internal_error"
+)
+
+// Error is the body of every error response, wrapped as {"error": ...}.
+type Error struct {
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type errorEnvelope struct {
+	Error Error `json:"error"`
+}
+
+// WriteError replies to the request with the error envelope and status.
+func WriteError(w http.ResponseWriter, status int, e Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(errorEnvelope{e}); err != nil {
+		log.Printf("Couldn't write error response: %v", err)
+	}
+}
+
+// MissingParam replies that a required URL parameter wasn't passed.
+func MissingParam(w http.ResponseWriter, param string) {
+	WriteError(w, http.StatusBadRequest, Error{
+		Code:    CodeMissingParam,
+		Param:   param,
+		Message: "Url Param '" + param + "' is missing",
+	})
+}
+
+// MissingBodyParam replies that a required JSON body field is missing.
+func MissingBodyParam(w http.ResponseWriter, param string) {
+	WriteError(w, http.StatusBadRequest, Error{
+		Code:    CodeMissingParam,
+		Param:   param,
+		Message: "Body param '" + param + "' is missing",
+	})
+}
+
+// InvalidParam replies that a URL parameter couldn't be parsed.
+func InvalidParam(w http.ResponseWriter, param string) {
+	WriteError(w, http.StatusBadRequest, Error{
+		Code:    CodeInvalidParam,
+		Param:   param,
+		Message: "Url Param '" + param + "' is of incorrect type",
+	})
+}
+
+// MethodNotAllowed replies that the endpoint doesn't support the request's
+// method, listing the ones it does.
+func MethodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	WriteError(w, http.StatusMethodNotAllowed, Error{
+		Code:    CodeMethod,
+		Message: "Method not allowed. Use " + strings.Join(allowed, " or ") + ".",
+	})
+}
+
+// InternalError replies with a generic server error. The cause should be
+// logged by the caller, never sent to the client.
+func InternalError(w http.ResponseWriter) {
+	WriteError(w, http.StatusInternalServerError, Error{
+		Code:    CodeInternal,
+		Message: "Something went wrong. Please try again later.",
+	})
+}
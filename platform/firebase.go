@@ -0,0 +1,88 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	firebase "firebase.google.com/go"
+)
+
+// AllowedEmailDomain is the only domain whose accounts may sign in.
+const AllowedEmailDomain = "berkeley.edu"
+
+// Reasons a Firebase sign-in is refused.
+var (
+	ErrInvalidIDToken  = errors.New("Firebase ID token is invalid")
+	ErrUnverifiedEmail = errors.New("email is not a verified " + AllowedEmailDomain + " address")
+)
+
+// FirebaseUser is the account a Firebase ID token was issued to.
+type FirebaseUser struct {
+	UID   string
+	Email string
+}
+
+// VerifyIDToken checks a Firebase ID token and returns its account, which
+// must have a verified email address on AllowedEmailDomain. Rejected tokens
+// produce an error wrapping ErrInvalidIDToken or ErrUnverifiedEmail.
+func VerifyIDToken(ctx context.Context, idToken string) (*FirebaseUser, error) {
+	app, err := firebase.NewApp(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("firebase app init failed: %v", err)
+	}
+	client, err := app.Auth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("firebase auth init failed: %v", err)
+	}
+	token, err := client.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	email, ok := verifiedEmail(token.Claims)
+	if !ok {
+		return nil, ErrUnverifiedEmail
+	}
+	return &FirebaseUser{UID: token.UID, Email: email}, nil
+}
+
+// IDTokenError answers a request whose Firebase ID token VerifyIDToken
+// refused with err.
+func IDTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidIDToken):
+		log.Printf("ID token verification failed: %v", err)
+		WriteError(w, http.StatusUnauthorized, Error{
+			Code:    CodeInvalidToken,
+			Param:   "id-token",
+			Message: "The Firebase ID token is invalid or has expired. Please sign in again.",
+		})
+	case errors.Is(err, ErrUnverifiedEmail):
+		WriteError(w, http.StatusForbidden, Error{
+			Code:    CodeForbidden,
+			Message: "Please sign in with a verified " + AllowedEmailDomain + " email address.",
+		})
+	default:
+		InternalError(w)
+		log.Printf("ID token verification failed: %v", err)
+	}
+}
+
+// verifiedEmail returns the email address of an ID token's claims if the
+// provider verified it and it is on AllowedEmailDomain itself, not merely a
+// domain ending in it.
+func verifiedEmail(claims map[string]interface{}) (string, bool) {
+	email, _ := claims["email"].(string)
+	verified, _ := claims["email_verified"].(bool)
+	if !verified {
+		return "", false
+	}
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || !strings.EqualFold(email[at+1:], AllowedEmailDomain) {
+		return "", false
+	}
+	return email, true
+}
@@ -0,0 +1,29 @@
+package platform
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/option"
+)
+
+var facilitiesProject = "berkeley-mobile"
+var usersProject = "api-team-292919"
+
+// NewFirestoreClient opens the berkeley-mobile project that holds the gym,
+// library, dining hall and campus resource collections.
+func NewFirestoreClient(ctx context.Context) (*firestore.Client, error) {
+	/* Get Auth for accessing Firestore by getting firestore secret */
+	key, err := Secret(ctx, FirestoreKeySecret)
+	if err != nil {
+		return nil, err
+	}
+	opt := option.WithCredentialsJSON([]byte(key))
+	return firestore.NewClient(ctx, facilitiesProject, opt)
+}
+
+// NewUsersFirestoreClient opens the project that holds the API users, using
+// the application default credentials.
+func NewUsersFirestoreClient(ctx context.Context) (*firestore.Client, error) {
+	return firestore.NewClient(ctx, usersProject)
+}
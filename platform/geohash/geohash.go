@@ -0,0 +1,116 @@
+// Package geohash encodes coordinates as geohashes, base 32 strings in which
+// every character narrows the cell a point falls in, so that points sharing a
+// prefix are close together and a range scan over a sorted geohash field
+// finds the points in one cell.
+package geohash
+
+import (
+	"math"
+	"strings"
+)
+
+// Precision is the length of the geohashes stored with documents, which
+// locates them within about 5 meters.
+const Precision = 9
+
+const alphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// earthRadiusKm is the mean radius of the Earth.
+const earthRadiusKm = 6371
+
+// Encode returns the geohash of a point with precision characters.
+func Encode(latitude, longitude float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	var hash strings.Builder
+	even := true
+	bit, ch := 0, 0
+	for hash.Len() < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if longitude >= mid {
+				ch = ch<<1 | 1
+				minLon = mid
+			} else {
+				ch <<= 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if latitude >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch <<= 1
+				maxLat = mid
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			hash.WriteByte(alphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+// cellSize returns the height and width in degrees of the cells of geohashes
+// with precision characters.
+func cellSize(precision int) (float64, float64) {
+	bits := 5 * precision
+	latBits := bits / 2
+	lonBits := bits - latBits
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lonBits))
+}
+
+// Cover returns geohash prefixes whose cells together contain every point
+// within radiusKm kilometers of a point: the cell the point falls in and its
+// eight neighbors, at the longest precision up to Precision whose cells are
+// larger than the circle. It returns nil when no precision is coarse enough
+// or the circle reaches a pole, and the whole globe has to be searched.
+func Cover(latitude, longitude, radiusKm float64) []string {
+	d := radiusKm / earthRadiusKm
+	latSpan := d * 180 / math.Pi
+	if math.Abs(latitude)+latSpan >= 90 {
+		return nil
+	}
+	// The widest longitude span of a circle on a sphere.
+	sinLon := math.Sin(d) / math.Cos(latitude*math.Pi/180)
+	if sinLon >= 1 {
+		return nil
+	}
+	lonSpan := math.Asin(sinLon) * 180 / math.Pi
+	for precision := Precision; precision >= 1; precision-- {
+		height, width := cellSize(precision)
+		if height < latSpan || width < lonSpan {
+			continue
+		}
+		seen := make(map[string]bool, 9)
+		prefixes := make([]string, 0, 9)
+		for _, dLat := range []float64{-height, 0, height} {
+			for _, dLon := range []float64{-width, 0, width} {
+				lat := latitude + dLat
+				if lat < -90 || lat > 90 {
+					continue
+				}
+				hash := Encode(lat, wrapLongitude(longitude+dLon), precision)
+				if !seen[hash] {
+					seen[hash] = true
+					prefixes = append(prefixes, hash)
+				}
+			}
+		}
+		return prefixes
+	}
+	return nil
+}
+
+func wrapLongitude(longitude float64) float64 {
+	if longitude >= 180 {
+		return longitude - 360
+	}
+	if longitude < -180 {
+		return longitude + 360
+	}
+	return longitude
+}
@@ -0,0 +1,85 @@
+package geohash
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		latitude, longitude float64
+		precision           int
+		want                string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{0, 0, 5, "s0000"},
+		{-90, -180, 3, "000"},
+		{37.8686, -122.2627, 5, "9q9p3"},
+	}
+	for _, test := range tests {
+		if got := Encode(test.latitude, test.longitude, test.precision); got != test.want {
+			t.Errorf("Encode(%v, %v, %d) = %s, want %s", test.latitude, test.longitude, test.precision, got, test.want)
+		}
+	}
+}
+
+// destination returns the point km kilometers from a point along bearing,
+// in degrees clockwise from north.
+func destination(latitude, longitude, km, bearing float64) (float64, float64) {
+	d := km / earthRadiusKm
+	lat1, lon1, b := latitude*math.Pi/180, longitude*math.Pi/180, bearing*math.Pi/180
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(b))
+	lon2 := lon1 + math.Atan2(math.Sin(b)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return lat2 * 180 / math.Pi, wrapLongitude(lon2 * 180 / math.Pi)
+}
+
+func TestCover(t *testing.T) {
+	tests := []struct {
+		name                string
+		latitude, longitude float64
+		radiusKm            float64
+		precision           int // of the prefixes, 0 when Cover returns nil
+	}{
+		{"a few meters", 37.8686, -122.2627, 0.001, 9},
+		{"a campus", 37.8686, -122.2627, 1, 5},
+		{"a city", 37.8686, -122.2627, 20, 3},
+		{"a state", 37.8686, -122.2627, 500, 2},
+		{"across the antimeridian", -17.7134, 179.9999, 5, 4},
+		{"across the equator", 0.0001, 36.8, 5, 4},
+		{"reaching a pole", 89.9, 0, 50, 0},
+		{"half the globe", 0, 0, 10000, 0},
+	}
+	for _, test := range tests {
+		prefixes := Cover(test.latitude, test.longitude, test.radiusKm)
+		if test.precision == 0 {
+			if prefixes != nil {
+				t.Errorf("%s: Cover = %v, want nil", test.name, prefixes)
+			}
+			continue
+		}
+		if len(prefixes) == 0 || len(prefixes) > 9 {
+			t.Fatalf("%s: Cover returned %d prefixes", test.name, len(prefixes))
+		}
+		for _, prefix := range prefixes {
+			if len(prefix) != test.precision {
+				t.Errorf("%s: prefix %s has precision %d, want %d", test.name, prefix, len(prefix), test.precision)
+			}
+		}
+		// Every point on and within the circle falls in a covered cell.
+		for _, fraction := range []float64{0, 0.5, 0.999} {
+			for bearing := 0.0; bearing < 360; bearing += 15 {
+				lat, lon := destination(test.latitude, test.longitude, test.radiusKm*fraction, bearing)
+				hash := Encode(lat, lon, Precision)
+				covered := false
+				for _, prefix := range prefixes {
+					covered = covered || strings.HasPrefix(hash, prefix)
+				}
+				if !covered {
+					t.Errorf("%s: point %.6f,%.6f (%s) at bearing %v isn't covered by %v",
+						test.name, lat, lon, hash, bearing, prefixes)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package platform
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useES256Keys makes kid the active signing key, with a new P-256 key, and
+// publishes its public half, through the OCTOAPI_SECRET_* overrides.
+func useES256Keys(t *testing.T, kid string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err := PublicJWK(kid, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing, _ := json.Marshal(map[string]interface{}{
+		"active": kid,
+		"keys": []map[string]string{{
+			"kid":         kid,
+			"alg":         "ES256",
+			"private_key": string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})),
+		}},
+	})
+	public, _ := json.Marshal(JWKS{Keys: []JWK{jwk}})
+	t.Setenv(JwtAlgEnv, "ES256")
+	t.Setenv(secretEnvName(SigningKeysSecret), string(signing))
+	t.Setenv(secretEnvName(PublicKeysSecret), string(public))
+}
+
+// clearKeyCache empties the key cache now and after the test.
+func clearKeyCache(t *testing.T) {
+	empty := func() {
+		keyCache.Lock()
+		defer keyCache.Unlock()
+		keyCache.signing, keyCache.public = nil, nil
+	}
+	empty()
+	t.Cleanup(empty)
+}
+
+func TestLoadKeysConcurrently(t *testing.T) {
+	clearKeyCache(t)
+	useES256Keys(t, "k1")
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if key, err := loadSigningKey(ctx); err != nil || key.kid != "k1" {
+				t.Errorf("loadSigningKey = %v, %v", key, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if set, err := loadPublicKeys(ctx); err != nil || len(set.keys) != 1 {
+				t.Errorf("loadPublicKeys = %v, %v", set, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLoadKeysAfterRoll(t *testing.T) {
+	clearKeyCache(t)
+	useES256Keys(t, "k1")
+	ctx := context.Background()
+	if key, err := loadSigningKey(ctx); err != nil || key.kid != "k1" {
+		t.Fatalf("loadSigningKey = %v, %v", key, err)
+	}
+
+	// Until the cached copy expires, the old key is still used.
+	useES256Keys(t, "k2")
+	if key, _ := loadSigningKey(ctx); key.kid != "k1" {
+		t.Errorf("cached key = %s, want k1", key.kid)
+	}
+	keyCache.Lock()
+	keyCache.signingExpires = time.Now().Add(-time.Second)
+	keyCache.Unlock()
+	if key, _ := loadSigningKey(ctx); key.kid != "k2" {
+		t.Errorf("key after expiry = %s, want k2", key.kid)
+	}
+}
@@ -0,0 +1,39 @@
+package platform
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// lazyInitTimeout bounds creating a lazily built process-wide value.
+const lazyInitTimeout = 30 * time.Second
+
+// lazy holds a process-wide value built on first use. Unlike sync.Once it
+// only keeps a value that was built successfully: after an error, such as a
+// secret that couldn't be read, the next call tries again.
+type lazy[T any] struct {
+	mu    sync.Mutex
+	done  bool
+	value T
+}
+
+// get returns the value, building it with init if no call has yet. init gets
+// its own deadline rather than the caller's context, since the value outlives
+// the request that happens to build it.
+func (l *lazy[T]) get(ctx context.Context, init func(context.Context) (T, error)) (T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return l.value, nil
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lazyInitTimeout)
+	defer cancel()
+	value, err := init(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	l.value, l.done = value, true
+	return value, nil
+}
@@ -0,0 +1,37 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLazyRetriesAfterError(t *testing.T) {
+	var l lazy[int]
+	calls := 0
+	init := func(ctx context.Context) (int, error) {
+		calls++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("init ran without a deadline")
+		}
+		if calls == 1 {
+			return 0, errors.New("unavailable")
+		}
+		return 42, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.get(ctx, init); err == nil {
+		t.Fatal("first get: want the init error")
+	}
+	for i := 0; i < 2; i++ {
+		v, err := l.get(ctx, init)
+		if err != nil || v != 42 {
+			t.Fatalf("get after error = %d, %v; want 42, nil", v, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("init ran %d times, want 2", calls)
+	}
+}
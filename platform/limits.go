@@ -0,0 +1,111 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/ratelimit"
+)
+
+// RateLimitBackendEnv selects where rate limit buckets live: "memory" (the
+// default) counts per instance, "firestore" shares buckets between instances
+// in the users project and "off" disables rate limiting.
+const RateLimitBackendEnv = "OCTOAPI_RATE_LIMIT_BACKEND"
+
+// CodeRateLimited is returned with 429 when a caller's bucket is empty.
+const CodeRateLimited = "rate_limited"
+
+// DefaultRateLimit applies to every user and API key on each endpoint without
+// a limit of its own in scopeRateLimits.
+var DefaultRateLimit = ratelimit.PerMinute(60)
+
+// scopeRateLimits gives the endpoints requiring a scope their own, separate
+// bucket. Transit and weather forward every call to upstream APIs whose
+// quotas we share.
+var scopeRateLimits = map[string]ratelimit.Limit{
+	ScopeTransitRead: ratelimit.PerMinute(20),
+	ScopeWeatherRead: ratelimit.PerMinute(20),
+}
+
+var (
+	memoryLimiter    = ratelimit.NewMemory()
+	firestoreLimiter lazy[ratelimit.Backend]
+)
+
+// rateLimiter returns the backend selected by OCTOAPI_RATE_LIMIT_BACKEND, or
+// nil when rate limiting is off. The backend is shared by the whole process.
+func rateLimiter(ctx context.Context) (ratelimit.Backend, error) {
+	switch backend := os.Getenv(RateLimitBackendEnv); backend {
+	case "", "memory":
+		return memoryLimiter, nil
+	case "firestore":
+		return firestoreLimiter.get(ctx, func(context.Context) (ratelimit.Backend, error) {
+			// The client outlives this call, so it mustn't hold its context.
+			client, err := NewUsersFirestoreClient(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			return ratelimit.NewFirestore(client), nil
+		})
+	case "off":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown backend %q", RateLimitBackendEnv, backend)
+	}
+}
+
+// RateLimit takes a request from the bucket of the user or API key the
+// claims belong to and sets the X-RateLimit-* headers. When the bucket is
+// empty it replies with 429 and Retry-After and returns false. Backend
+// failures are logged and let the request through.
+func RateLimit(w http.ResponseWriter, r *http.Request, claims *Claims, scopes ...string) bool {
+	limiter, err := rateLimiter(r.Context())
+	if err != nil {
+		log.Printf("rate limiter init failed: %v", err)
+		return true
+	}
+	if limiter == nil {
+		return true
+	}
+
+	key := "uid:" + claims.UID
+	if claims.Type == TokenTypeAPIKey {
+		key = "key:" + claims.Id
+	}
+	limit, bucket := DefaultRateLimit, "default"
+	for _, scope := range scopes {
+		if scopeLimit, ok := scopeRateLimits[scope]; ok {
+			limit, bucket = scopeLimit, scope
+			break
+		}
+	}
+	result, err := limiter.Take(r.Context(), key+"|"+bucket, limit, time.Now())
+	if err != nil {
+		log.Printf("rate limit check failed: %v", err)
+		return true
+	}
+	header := w.Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("X-RateLimit-Reset", ceilSeconds(result.Reset))
+	if result.Allowed {
+		return true
+	}
+	retryAfter := ceilSeconds(result.RetryAfter)
+	header.Set("Retry-After", retryAfter)
+	WriteError(w, http.StatusTooManyRequests, Error{
+		Code:    CodeRateLimited,
+		Message: "Too many requests. Please retry in " + retryAfter + "s.",
+	})
+	return false
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
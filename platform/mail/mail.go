@@ -0,0 +1,112 @@
+// Package mail sends the plain-text notices the API emails to users. Notices
+// only say that something happened to an account; they never carry tokens,
+// keys or secrets.
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	sendgrid "github.com/sendgrid/sendgrid-go"
+	sgmail "github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// Sender of every notice.
+const (
+	FromName    = "OCTO API"
+	FromAddress = "octo.api@asuc.org"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	Time    time.Time `json:"time"`
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SendGrid is a Mailer sending through the SendGrid API.
+type SendGrid struct {
+	client *sendgrid.Client
+}
+
+// NewSendGrid returns a Mailer using the SendGrid API key apiKey.
+func NewSendGrid(apiKey string) *SendGrid {
+	return &SendGrid{client: sendgrid.NewSendClient(apiKey)}
+}
+
+func (m *SendGrid) Send(ctx context.Context, msg Message) error {
+	message := sgmail.NewSingleEmailPlainText(sgmail.NewEmail(FromName, FromAddress), msg.Subject,
+		sgmail.NewEmail("", msg.To), msg.Body)
+	resp, err := m.client.SendWithContext(ctx, message)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sendgrid: status %d: %s", resp.StatusCode, resp.Body)
+	}
+	return nil
+}
+
+// SMTP is a Mailer handing messages to an SMTP server without
+// authentication, such as a local MailHog or Mailpit for testing.
+type SMTP struct {
+	addr string
+}
+
+// NewSMTP returns a Mailer sending through the SMTP server at addr.
+func NewSMTP(addr string) *SMTP {
+	return &SMTP{addr: addr}
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s <%s>\r\n", FromName, FromAddress)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", msg.Time.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return smtp.SendMail(m.addr, nil, FromAddress, []string{msg.To}, []byte(b.String()))
+}
+
+// File is a Mailer appending each message as a JSON line to a file, for
+// local runs and tests.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFile returns a Mailer writing to the file at path.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (m *File) Send(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
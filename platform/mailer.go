@@ -0,0 +1,148 @@
+package platform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/mail"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// MailerEnv selects how notices are emailed: "sendgrid" (the default) uses
+// the sendgrid_key secret, "smtp" hands them to the server at
+// OCTOAPI_SMTP_ADDR, "file" appends them to OCTOAPI_MAIL_FILE and "off"
+// drops them.
+const MailerEnv = "OCTOAPI_MAILER"
+
+// Settings of the local mailers.
+const (
+	SMTPAddrEnv = "OCTOAPI_SMTP_ADDR"
+	MailFileEnv = "OCTOAPI_MAIL_FILE"
+)
+
+// sendTimeout bounds sending a notice. Notices are sent before the response,
+// since a Cloud Function may be frozen as soon as it has replied.
+const sendTimeout = 30 * time.Second
+
+// PortalURLEnv sets the developer portal address linked from notices.
+const PortalURLEnv = "OCTOAPI_PORTAL_URL"
+
+var mailer lazy[mail.Mailer]
+
+// Mailer returns the mailer selected by OCTOAPI_MAILER, or nil when mail is
+// off. The mailer is shared by the whole process; if it can't be set up, the
+// next call tries again.
+func Mailer(ctx context.Context) (mail.Mailer, error) {
+	return mailer.get(ctx, func(ctx context.Context) (mail.Mailer, error) {
+		switch backend := os.Getenv(MailerEnv); backend {
+		case "", "sendgrid":
+			key, err := Secret(ctx, SendGridKeySecret)
+			if err != nil {
+				return nil, err
+			}
+			return mail.NewSendGrid(key), nil
+		case "smtp":
+			addr := os.Getenv(SMTPAddrEnv)
+			if addr == "" {
+				addr = "localhost:1025"
+			}
+			return mail.NewSMTP(addr), nil
+		case "file":
+			path := os.Getenv(MailFileEnv)
+			if path == "" {
+				path = "mail.jsonl"
+			}
+			return mail.NewFile(path), nil
+		case "off":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("%s: unknown mailer %q", MailerEnv, backend)
+		}
+	})
+}
+
+// Notices go out for new API keys, new OAuth grants and logins from new
+// devices only, so that they stay worth reading. Each names what happened but
+// never contains a credential. Users without a known email address aren't
+// notified, and a notice that can't be sent is logged rather than failing the
+// request.
+
+// NotifyCredentialIssued emails user that a credential of the given kind,
+// named name, was issued for their account.
+func NotifyCredentialIssued(ctx context.Context, user *users.User, kind, name string) {
+	what := kind
+	if name != "" {
+		what = fmt.Sprintf("%s %q", kind, name)
+	}
+	notify(ctx, user, "New "+kind+" for your OCTO API account",
+		"A new %s was issued for your OCTO API account on %s.\n\n"+
+			"We never send credentials by email. If this wasn't you, revoke it "+
+			"from %s.\n", what)
+}
+
+// NotifyNewGrant emails user that they allowed the OAuth app named name to
+// access their account.
+func NotifyNewGrant(ctx context.Context, user *users.User, name string) {
+	notify(ctx, user, "New app connected to your OCTO API account",
+		"You allowed %q to access your OCTO API account on %s.\n\n"+
+			"If this wasn't you, sign out of the app and check your account "+
+			"from %s.\n", name)
+}
+
+// NotifyNewDevice emails user that their account was logged in to from the
+// device described by userAgent.
+func NotifyNewDevice(ctx context.Context, user *users.User, userAgent string) {
+	if userAgent == "" {
+		userAgent = "an unknown device"
+	} else if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength] + "..."
+	}
+	notify(ctx, user, "New login to your OCTO API account",
+		"Your OCTO API account was logged in to from a new device (%s) on %s.\n\n"+
+			"If this wasn't you, revoke your sessions and API keys from %s.\n", userAgent)
+}
+
+// maxUserAgentLength bounds the device description quoted in a notice.
+const maxUserAgentLength = 200
+
+// notify emails user a notice whose body is format filled in with what, the
+// time and the portal address.
+func notify(ctx context.Context, user *users.User, subject, format, what string) {
+	if user.Email == "" {
+		return
+	}
+	now := time.Now()
+	portal := "the developer portal"
+	if url := os.Getenv(PortalURLEnv); url != "" {
+		portal = url
+	}
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf(format, what, now.UTC().Format("Jan 2, 2006 at 15:04 MST"), portal),
+		Time:    now,
+	}
+	// A client hanging up mustn't cut the notice short.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+	defer cancel()
+	m, err := Mailer(ctx)
+	if err == nil && m != nil {
+		err = m.Send(ctx, msg)
+	}
+	if err != nil {
+		log.Printf("notice to %s failed: %v", user.UID, err)
+	}
+}
+
+// DeviceID identifies the device a request comes from by a hash of its
+// User-Agent, which is enough to tell a user's usual browsers and apps apart.
+func DeviceID(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.UserAgent()))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,157 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/usage"
+)
+
+// UsageSinkEnv selects where usage records go: "memory" keeps them per
+// instance, "firestore" writes them to the usage collection of the users
+// project, "log" prints them and "off" drops them. It has no default: only a
+// process serving every endpoint, like cmd/octoapi, can use "memory" and still
+// report all of its usage.
+const UsageSinkEnv = "OCTOAPI_USAGE_SINK"
+
+// ErrNoUsageSink is returned by UsageSink when OCTOAPI_USAGE_SINK is unset.
+var ErrNoUsageSink = errors.New(UsageSinkEnv + " is not set: use firestore, log or off, or memory when one process serves every endpoint")
+
+// recordTimeout bounds writing a usage record once the handler is done.
+const recordTimeout = 10 * time.Second
+
+var usageSink lazy[usage.Sink]
+
+// UsageSink returns the sink selected by OCTOAPI_USAGE_SINK, or nil when
+// metering is off. The sink is shared by the whole process; if it can't be
+// opened, the next call tries again.
+func UsageSink(ctx context.Context) (usage.Sink, error) {
+	return usageSink.get(ctx, func(context.Context) (usage.Sink, error) {
+		switch sink := os.Getenv(UsageSinkEnv); sink {
+		case "":
+			return nil, ErrNoUsageSink
+		case "memory":
+			return usage.NewMemory(), nil
+		case "firestore":
+			// The client outlives this call, so it mustn't hold its context.
+			client, err := NewUsersFirestoreClient(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			return usage.NewFirestore(client), nil
+		case "log":
+			return usage.Log{}, nil
+		case "off":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("%s: unknown sink %q", UsageSinkEnv, sink)
+		}
+	})
+}
+
+type meterKey struct{}
+
+// meter collects what Meter records about a request while its handler runs.
+type meter struct {
+	uid   string
+	keyID string
+}
+
+// Meter wraps an endpoint so every request its handler authenticates is
+// recorded to the usage sink under the endpoint's name, with the response
+// status and latency. The record is written before Meter returns: a Cloud
+// Function may be frozen as soon as its handler does, so nothing can be left
+// running in the background.
+func Meter(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m := &meter{}
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		handler(sw, r.WithContext(context.WithValue(r.Context(), meterKey{}, m)))
+		if m.uid == "" {
+			return
+		}
+		record := usage.Record{
+			UID:       m.uid,
+			KeyID:     m.keyID,
+			Endpoint:  endpoint,
+			Method:    r.Method,
+			Status:    sw.status,
+			LatencyMs: time.Since(start).Milliseconds(),
+			Time:      start.Unix(),
+		}
+		// A client hanging up after the response mustn't lose the record.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), recordTimeout)
+		defer cancel()
+		sink, err := UsageSink(ctx)
+		if err == nil && sink != nil {
+			err = sink.Record(ctx, record)
+		}
+		if err != nil {
+			log.Printf("usage record failed: %v", err)
+		}
+	}
+}
+
+// meterCaller tells the enclosing Meter, if any, who made the request.
+func meterCaller(r *http.Request, claims *Claims) {
+	m, ok := r.Context().Value(meterKey{}).(*meter)
+	if !ok {
+		return
+	}
+	m.uid = claims.UID
+	if claims.Type == TokenTypeAPIKey {
+		m.keyID = claims.Id
+	}
+}
+
+// statusWriter remembers the status code a handler replies with.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// defaultUsageRange is the period usage is reported over when the request
+// doesn't say.
+const defaultUsageRange = 30 * 24 * time.Hour
+
+// UsageQuery reads the from and to query params, in Unix seconds with to
+// exclusive, into a usage query. They default to the last 30 days up to and
+// including now. On a bad param it replies with 400 and returns false.
+func UsageQuery(w http.ResponseWriter, r *http.Request) (usage.Query, bool) {
+	q := usage.Query{To: time.Now().Unix() + 1}
+	for _, param := range []struct {
+		name  string
+		value *int64
+	}{{"to", &q.To}, {"from", &q.From}} {
+		raw := r.URL.Query().Get(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			InvalidParam(w, param.name)
+			return q, false
+		}
+		*param.value = value
+	}
+	if q.From == 0 {
+		q.From = q.To - int64(defaultUsageRange/time.Second)
+	}
+	if q.From >= q.To {
+		InvalidParam(w, "from")
+		return q, false
+	}
+	return q, true
+}
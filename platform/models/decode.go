@@ -0,0 +1,188 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+// DecodeGym reads and validates a gym document.
+func DecodeGym(doc store.Document) (Gym, error) {
+	d := decoder{doc: doc}
+	gym := Gym{
+		Place:      d.place(),
+		Hours:      d.openingHours(),
+		Phone:      d.string("phone"),
+		TrackHours: d.hours("track_hours"),
+		PoolHours:  d.hours("pool_hours"),
+	}
+	if d.err != nil {
+		return Gym{}, d.err
+	}
+	return gym, gym.Validate()
+}
+
+// DecodeLibrary reads and validates a library document.
+func DecodeLibrary(doc store.Document) (Library, error) {
+	d := decoder{doc: doc}
+	library := Library{
+		Place: d.place(),
+		Hours: d.openingHours(),
+	}
+	if d.err != nil {
+		return Library{}, d.err
+	}
+	return library, library.Validate()
+}
+
+// DecodeDiningHall reads and validates a dining hall document.
+func DecodeDiningHall(doc store.Document) (DiningHall, error) {
+	d := decoder{doc: doc}
+	dining := DiningHall{
+		Place: d.place(),
+		Hours: d.openingHours(),
+		Phone: d.string("phone"),
+	}
+	if d.err != nil {
+		return DiningHall{}, d.err
+	}
+	return dining, dining.Validate()
+}
+
+// DecodeResource reads and validates a campus resource document.
+func DecodeResource(doc store.Document) (Resource, error) {
+	d := decoder{doc: doc}
+	resource := Resource{
+		Place:    d.place(),
+		Hours:    d.openingHours(),
+		Category: d.string("category"),
+		Phone:    d.string("phone"),
+		Email:    d.string("email"),
+	}
+	if d.err != nil {
+		return Resource{}, d.err
+	}
+	return resource, resource.Validate()
+}
+
+// decoder reads typed fields out of a document, remembering the first error
+// so callers can check once at the end.
+type decoder struct {
+	doc store.Document
+	err error
+}
+
+func (d *decoder) fail(field string, value interface{}) {
+	if d.err == nil {
+		name, _ := d.doc["name"].(string)
+		d.err = fmt.Errorf("%s: field %s has unexpected type %T", name, field, value)
+	}
+}
+
+func (d *decoder) place() Place {
+	return Place{
+		Name:        d.string("name"),
+		Description: d.string("description"),
+		Address:     d.string("address"),
+		Latitude:    d.float("latitude"),
+		Longitude:   d.float("longitude"),
+	}
+}
+
+func (d *decoder) openingHours() Hours {
+	hours := Hours{OpenCloseHours: d.hours("open_close_array")}
+	d.json("weekly_hours", &hours.WeeklyHours)
+	d.json("exceptions", &hours.Exceptions)
+	return hours
+}
+
+// json decodes a nested field into v by way of its JSON encoding, which
+// reads maps from Firestore and encoding/json alike.
+func (d *decoder) json(field string, v interface{}) {
+	value, present := d.doc[field]
+	if !present || value == nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		d.fail(field, value)
+	}
+}
+
+func (d *decoder) string(field string) string {
+	switch v := d.doc[field].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		d.fail(field, v)
+		return ""
+	}
+}
+
+func (d *decoder) float(field string) float64 {
+	value, present := d.doc[field]
+	if !present || value == nil {
+		return 0
+	}
+	f, ok := toFloat(value)
+	if !ok {
+		d.fail(field, value)
+	}
+	return f
+}
+
+func (d *decoder) hours(field string) OpeningHours {
+	var entries []map[string]interface{}
+	switch v := d.doc[field].(type) {
+	case nil:
+		return OpeningHours{}
+	case []map[string]interface{}:
+		entries = v
+	case []interface{}:
+		for _, entry := range v {
+			m, ok := entry.(map[string]interface{})
+			if !ok {
+				d.fail(field, entry)
+				return OpeningHours{}
+			}
+			entries = append(entries, m)
+		}
+	default:
+		d.fail(field, v)
+		return OpeningHours{}
+	}
+	hours := make(OpeningHours, 0, len(entries))
+	for _, entry := range entries {
+		openTime, openOk := toFloat(entry["open_time"])
+		closeTime, closeOk := toFloat(entry["close_time"])
+		if !openOk || !closeOk {
+			d.fail(field, entry)
+			return OpeningHours{}
+		}
+		notes, _ := entry["notes"].(string)
+		hours = append(hours, Timing{OpenTime: int64(openTime), CloseTime: int64(closeTime), Notes: notes})
+	}
+	return hours
+}
+
+// toFloat accepts the numeric types produced by Firestore and encoding/json.
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/asuc-octo/octoapi/platform/schedule"
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+// DecodeHoliday reads and validates a holiday document.
+func DecodeHoliday(doc store.Document) (schedule.Holiday, error) {
+	d := decoder{doc: doc}
+	holiday := schedule.Holiday{
+		Date: d.string("date"),
+		Name: d.string("name"),
+	}
+	if d.err != nil {
+		return schedule.Holiday{}, d.err
+	}
+	if _, err := schedule.ParseDate(holiday.Date); err != nil {
+		return schedule.Holiday{}, fmt.Errorf("holiday %q: date %q isn't formatted as %s", holiday.Name, holiday.Date, schedule.DateLayout)
+	}
+	return holiday, nil
+}
+
+// LoadHolidays returns the campus holidays in the store, skipping invalid
+// ones.
+func LoadHolidays(ctx context.Context, db store.Store) ([]schedule.Holiday, error) {
+	docs, err := db.List(ctx, store.Holidays)
+	if err != nil {
+		return nil, err
+	}
+	holidays := make([]schedule.Holiday, 0, len(docs))
+	for _, doc := range docs {
+		holiday, err := DecodeHoliday(doc)
+		if err != nil {
+			log.Printf("Skipping invalid holiday: %v", err)
+			continue
+		}
+		holidays = append(holidays, holiday)
+	}
+	return holidays, nil
+}
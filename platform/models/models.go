@@ -0,0 +1,261 @@
+// Package models defines the facility types served by the API and decodes
+// them from raw store documents.
+//
+// Every facility is encoded with the fields of Place plus its own fields.
+// Opening hours are arrays of Timing objects, e.g.
+//
+//	{"open_time": 1602000000, "close_time": 1602036000, "notes": ""}
+//
+// where both times are Unix timestamps in seconds. Facilities may also carry
+// recurring weekly_hours and dated exceptions; see package schedule.
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/schedule"
+)
+
+// Timing is a single interval from open_close_array, track_hours or
+// pool_hours. An interval whose notes read "Closed" marks the facility as
+// closed for that period.
+type Timing struct {
+	OpenTime  int64  `json:"open_time"`
+	CloseTime int64  `json:"close_time"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+// Closed reports whether the interval is marked as closed.
+func (t Timing) Closed() bool {
+	return t.Notes == "Closed"
+}
+
+// OpeningHours is the list of intervals during which a facility is open.
+type OpeningHours []Timing
+
+// Periods returns the intervals as schedule periods.
+func (h OpeningHours) Periods() []schedule.Period {
+	periods := make([]schedule.Period, 0, len(h))
+	for _, timing := range h {
+		periods = append(periods, schedule.Period{
+			Open:   time.Unix(timing.OpenTime, 0),
+			Close:  time.Unix(timing.CloseTime, 0),
+			Notes:  timing.Notes,
+			Closed: timing.Closed(),
+		})
+	}
+	return periods
+}
+
+func (h OpeningHours) validate(field string) error {
+	for _, timing := range h {
+		if timing.CloseTime < timing.OpenTime {
+			return fmt.Errorf("%s: close_time %d is before open_time %d", field, timing.CloseTime, timing.OpenTime)
+		}
+	}
+	return nil
+}
+
+// Status is computed from a facility's hours for the requested time. Times
+// are Unix seconds and are null when the facility doesn't open or close
+// again within schedule.Lookahead.
+type Status struct {
+	IsOpen             bool   `json:"is_open"`
+	ClosesAt           *int64 `json:"closes_at"`
+	NextOpensAt        *int64 `json:"next_opens_at"`
+	MinutesUntilChange *int64 `json:"minutes_until_change"`
+}
+
+// Hours holds the opening hours shared by every facility that has them.
+type Hours struct {
+	OpenCloseHours OpeningHours         `json:"open_close_array"`
+	WeeklyHours    schedule.Weekly      `json:"weekly_hours,omitempty"`
+	Exceptions     []schedule.Exception `json:"exceptions,omitempty"`
+	Status
+}
+
+// Schedule returns the facility's schedule, closed on holidays unless its
+// dated hours or exceptions say otherwise.
+func (h Hours) Schedule(holidays []schedule.Holiday) schedule.Schedule {
+	return schedule.Schedule{
+		Intervals:  h.OpenCloseHours.Periods(),
+		Weekly:     h.WeeklyHours,
+		Exceptions: h.Exceptions,
+		Holidays:   holidays,
+	}
+}
+
+// StatusAt evaluates s at t.
+func StatusAt(s schedule.Schedule, t time.Time) Status {
+	status := s.StatusAt(t)
+	out := Status{
+		IsOpen:      status.Open,
+		ClosesAt:    unixTime(status.Closes),
+		NextOpensAt: unixTime(status.Opens),
+	}
+	if change := status.Change(); !change.IsZero() {
+		// Round up, so a facility closing in 30 seconds isn't 0 minutes away.
+		minutes := int64((change.Sub(t) + time.Minute - 1) / time.Minute)
+		out.MinutesUntilChange = &minutes
+	}
+	return out
+}
+
+// Evaluate sets the facility's Status at t.
+func (h *Hours) Evaluate(t time.Time, holidays []schedule.Holiday) {
+	h.Status = StatusAt(h.Schedule(holidays), t)
+}
+
+func unixTime(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	unix := t.Unix()
+	return &unix
+}
+
+func (h Hours) validate() error {
+	if err := h.OpenCloseHours.validate("open_close_array"); err != nil {
+		return err
+	}
+	if err := h.WeeklyHours.Validate(); err != nil {
+		return fmt.Errorf("weekly_hours: %v", err)
+	}
+	for _, ex := range h.Exceptions {
+		if err := ex.Validate(); err != nil {
+			return fmt.Errorf("exceptions: %v", err)
+		}
+	}
+	return nil
+}
+
+// Place holds the fields shared by every facility.
+type Place struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Address     string  `json:"address"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
+
+// Validate checks that the place is named and has a valid location.
+func (p Place) Validate() error {
+	if p.Name == "" {
+		return errors.New("name is missing")
+	}
+	if p.Latitude < -90 || p.Latitude > 90 {
+		return fmt.Errorf("%s: latitude %v is out of range", p.Name, p.Latitude)
+	}
+	if p.Longitude < -180 || p.Longitude > 180 {
+		return fmt.Errorf("%s: longitude %v is out of range", p.Name, p.Longitude)
+	}
+	return nil
+}
+
+// Parts of a gym with their own hours, as named by the facility param.
+const (
+	FacilityBuilding = "building"
+	FacilityPool     = "pool"
+	FacilityTrack    = "track"
+)
+
+// ValidGymFacility reports whether facility names a part of a gym.
+func ValidGymFacility(facility string) bool {
+	return facility == FacilityBuilding || facility == FacilityPool || facility == FacilityTrack
+}
+
+// Gym is a document from the Gyms collection.
+type Gym struct {
+	Place
+	Hours
+	Phone      string       `json:"phone"`
+	TrackHours OpeningHours `json:"track_hours"`
+	PoolHours  OpeningHours `json:"pool_hours"`
+}
+
+// FacilitySchedule returns the schedule of the gym's building, pool or track.
+// The pool and track only have dated hours.
+func (g Gym) FacilitySchedule(facility string, holidays []schedule.Holiday) schedule.Schedule {
+	switch facility {
+	case FacilityPool:
+		return schedule.Schedule{Intervals: g.PoolHours.Periods(), Holidays: holidays}
+	case FacilityTrack:
+		return schedule.Schedule{Intervals: g.TrackHours.Periods(), Holidays: holidays}
+	default:
+		return g.Schedule(holidays)
+	}
+}
+
+// Validate checks the gym's location and hours.
+func (g Gym) Validate() error {
+	if err := g.Place.Validate(); err != nil {
+		return err
+	}
+	if err := g.Hours.validate(); err != nil {
+		return fmt.Errorf("%s: %v", g.Name, err)
+	}
+	if err := g.TrackHours.validate("track_hours"); err != nil {
+		return fmt.Errorf("%s: %v", g.Name, err)
+	}
+	if err := g.PoolHours.validate("pool_hours"); err != nil {
+		return fmt.Errorf("%s: %v", g.Name, err)
+	}
+	return nil
+}
+
+// Library is a document from the Libraries collection.
+type Library struct {
+	Place
+	Hours
+}
+
+// Validate checks the library's location and hours.
+func (l Library) Validate() error {
+	if err := l.Place.Validate(); err != nil {
+		return err
+	}
+	if err := l.Hours.validate(); err != nil {
+		return fmt.Errorf("%s: %v", l.Name, err)
+	}
+	return nil
+}
+
+// DiningHall is a document from the Dining Halls collection.
+type DiningHall struct {
+	Place
+	Hours
+	Phone string `json:"phone"`
+}
+
+// Validate checks the dining hall's location and hours.
+func (d DiningHall) Validate() error {
+	if err := d.Place.Validate(); err != nil {
+		return err
+	}
+	if err := d.Hours.validate(); err != nil {
+		return fmt.Errorf("%s: %v", d.Name, err)
+	}
+	return nil
+}
+
+// Resource is a document from the Campus Resource collection.
+type Resource struct {
+	Place
+	Hours
+	Category string `json:"category"`
+	Phone    string `json:"phone"`
+	Email    string `json:"email"`
+}
+
+// Validate checks the resource's location and hours.
+func (r Resource) Validate() error {
+	if err := r.Place.Validate(); err != nil {
+		return err
+	}
+	if err := r.Hours.validate(); err != nil {
+		return fmt.Errorf("%s: %v", r.Name, err)
+	}
+	return nil
+}
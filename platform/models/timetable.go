@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/schedule"
+)
+
+// Interval is one period of a facility's hours in a Timetable. Open and
+// Close repeat OpenTime and CloseTime as "15:04" in campus time.
+type Interval struct {
+	OpenTime  int64  `json:"open_time"`
+	CloseTime int64  `json:"close_time"`
+	Open      string `json:"open"`
+	Close     string `json:"close"`
+	Notes     string `json:"notes,omitempty"`
+	Closed    bool   `json:"closed"`
+}
+
+// Day lists a facility's hours on one campus day. A day without any open
+// interval is closed.
+type Day struct {
+	Date    string     `json:"date"`
+	Weekday string     `json:"weekday"`
+	Hours   []Interval `json:"hours"`
+}
+
+// Timetable is a facility's hours for each day from From to To, inclusive.
+type Timetable struct {
+	Name     string `json:"name"`
+	TimeZone string `json:"time_zone"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Days     []Day  `json:"days"`
+}
+
+// NewTimetable lists the periods of s for each campus day from from until to,
+// which must be local midnights as returned by schedule.Day.
+func NewTimetable(name string, s schedule.Schedule, from, to time.Time) Timetable {
+	table := Timetable{
+		Name:     name,
+		TimeZone: schedule.Location.String(),
+		From:     from.Format(schedule.DateLayout),
+		To:       to.AddDate(0, 0, -1).Format(schedule.DateLayout),
+		Days:     make([]Day, 0),
+	}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		hours := make([]Interval, 0)
+		for _, p := range s.DayPeriods(day) {
+			hours = append(hours, Interval{
+				OpenTime:  p.Open.Unix(),
+				CloseTime: p.Close.Unix(),
+				Open:      p.Open.In(schedule.Location).Format("15:04"),
+				Close:     p.Close.In(schedule.Location).Format("15:04"),
+				Notes:     p.Notes,
+				Closed:    p.Closed,
+			})
+		}
+		table.Days = append(table.Days, Day{
+			Date:    day.Format(schedule.DateLayout),
+			Weekday: strings.ToLower(day.Weekday().String()),
+			Hours:   hours,
+		})
+	}
+	return table
+}
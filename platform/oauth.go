@@ -0,0 +1,243 @@
+package platform
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/users"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// OpenID Connect scopes, which OAuth clients may request on top of the API
+// scopes. They only unlock the userinfo endpoint and the ID token.
+const (
+	ScopeOpenID = "openid"
+	ScopeEmail  = "email"
+)
+
+const (
+	// AuthCodeTTL is how long an authorization code can be exchanged.
+	AuthCodeTTL = 10 * time.Minute
+	// IDTokenTTL is how long an OpenID Connect ID token stays valid.
+	IDTokenTTL = time.Hour
+)
+
+// IssuerURLEnv sets the public base URL of the API, used as the issuer of ID
+// tokens and in the discovery document. It defaults to the scheme and host
+// the request came in on, which is only meant for local runs.
+const IssuerURLEnv = "OCTOAPI_ISSUER_URL"
+
+// OAuthScopes are the scopes an OAuth client may be registered for. Admin
+// scopes are never available to third-party apps.
+var OAuthScopes = append(append([]string(nil), DefaultScopes...), ScopeOpenID, ScopeEmail)
+
+// Reasons an OAuth client or authorization code is rejected.
+var (
+	ErrInvalidClient = errors.New("unknown client or wrong client secret")
+	ErrInvalidGrant  = errors.New("invalid authorization code")
+)
+
+func invalidGrant(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidGrant, reason)
+}
+
+// IDClaims is the payload of an OpenID Connect ID token.
+type IDClaims struct {
+	jwt.StandardClaims
+	Nonce         string `json:"nonce,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+}
+
+// NewClient registers an OAuth client owned by uid and returns its secret,
+// which is empty for public clients. Like API keys, only a hash of the secret
+// is stored.
+func NewClient(ctx context.Context, db users.Store, uid, name string, redirectURIs, scopes []string, public bool) (string, *users.Client, error) {
+	id, err := randomString(12)
+	if err != nil {
+		return "", nil, err
+	}
+	client := &users.Client{
+		ID:           id,
+		OwnerUID:     uid,
+		Name:         name,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+		CreatedAt:    time.Now().Unix(),
+	}
+	secret := ""
+	if !public {
+		if secret, err = randomString(32); err != nil {
+			return "", nil, err
+		}
+		client.SecretHash = hashAPIKeySecret(secret)
+	}
+	if err := db.CreateClient(ctx, client); err != nil {
+		return "", nil, err
+	}
+	return secret, client, nil
+}
+
+// AuthenticateClient returns the client with the given id after checking its
+// secret. Public clients must not send one.
+func AuthenticateClient(ctx context.Context, db users.Store, id, secret string) (*users.Client, error) {
+	client, err := db.GetClient(ctx, id)
+	if err == users.ErrNotFound {
+		return nil, ErrInvalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if client.Revoked() {
+		return nil, ErrInvalidClient
+	}
+	if client.Public() != (secret == "") {
+		return nil, ErrInvalidClient
+	}
+	if !client.Public() && subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashAPIKeySecret(secret))) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// ValidRedirectURI reports whether uri may be registered as a redirect URI:
+// an absolute https URL without a fragment, or plain http to localhost for
+// apps under development.
+func ValidRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return false
+}
+
+// NewAuthCode stores code, stamping its id and expiry, and returns the code
+// to hand to the client.
+func NewAuthCode(ctx context.Context, db users.Store, code users.AuthCode) (string, error) {
+	secret, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+	code.ID = hashAPIKeySecret(secret)
+	code.ExpiresAt = time.Now().Add(AuthCodeTTL).Unix()
+	if err := db.CreateAuthCode(ctx, &code); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// RedeemAuthCode exchanges an authorization code issued to client for
+// redirectURI, checking the PKCE verifier against the code's challenge. A
+// code can only be redeemed once. Rejected codes produce an error wrapping
+// ErrInvalidGrant.
+func RedeemAuthCode(ctx context.Context, db users.Store, secret, clientID, redirectURI, verifier string) (*users.AuthCode, error) {
+	var redeemed users.AuthCode
+	err := db.UpdateAuthCode(ctx, hashAPIKeySecret(secret), func(code *users.AuthCode) error {
+		now := time.Now().Unix()
+		switch {
+		case code.UsedAt != 0:
+			return invalidGrant("code has already been used")
+		case now >= code.ExpiresAt:
+			return invalidGrant("code has expired")
+		case code.ClientID != clientID:
+			return invalidGrant("code was issued to another client")
+		case code.RedirectURI != redirectURI:
+			return invalidGrant("redirect_uri doesn't match the authorization request")
+		case !VerifyCodeChallenge(verifier, code.CodeChallenge):
+			return invalidGrant("code_verifier doesn't match the code_challenge")
+		}
+		code.UsedAt = now
+		redeemed = *code
+		return nil
+	})
+	if err == users.ErrNotFound {
+		return nil, invalidGrant("unknown code")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &redeemed, nil
+}
+
+// VerifyCodeChallenge reports whether verifier hashes to an S256 PKCE
+// challenge (RFC 7636).
+func VerifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 || challenge == "" {
+		return false
+	}
+	for _, c := range verifier {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.ContainsRune("-._~", c)) {
+			return false
+		}
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
+
+// NewIDToken signs an OpenID Connect ID token telling client who user is.
+// The email is only included when the email scope was granted.
+func NewIDToken(ctx context.Context, issuer, clientID string, user *users.User, nonce string, withEmail bool) (string, error) {
+	now := time.Now()
+	claims := IDClaims{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    issuer,
+			Subject:   user.UID,
+			Audience:  clientID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(IDTokenTTL).Unix(),
+		},
+		Nonce: nonce,
+	}
+	if withEmail && user.Email != "" {
+		claims.Email, claims.EmailVerified = user.Email, true
+	}
+	return signToken(ctx, claims)
+}
+
+// SigningAlg returns the algorithm tokens are currently signed with.
+func SigningAlg(ctx context.Context) (string, error) {
+	method, err := hmacMethod()
+	if err != nil {
+		return "", err
+	}
+	if method != nil {
+		return method.Alg(), nil
+	}
+	key, err := loadSigningKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	return key.method.Alg(), nil
+}
+
+// IssuerURL returns the public base URL of the API as seen by the caller, and
+// whether it was configured by OCTOAPI_ISSUER_URL rather than taken from the
+// request's Host and X-Forwarded-Proto headers, which the caller controls.
+func IssuerURL(r *http.Request) (string, bool) {
+	if issuer := os.Getenv(IssuerURLEnv); issuer != "" {
+		return strings.TrimSuffix(issuer, "/"), true
+	}
+	scheme := "https"
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	} else if r.TLS == nil {
+		scheme = "http"
+	}
+	return scheme + "://" + r.Host, false
+}
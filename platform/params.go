@@ -0,0 +1,128 @@
+package platform
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform/schedule"
+)
+
+// MaxDateRange bounds the number of days DateRangeParams accepts.
+const MaxDateRange = 31
+
+// TimeParam returns the time given by the time query param in Unix seconds,
+// or now when it is absent. It replies 400 and returns false when the param
+// can't be parsed.
+func TimeParam(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	values, ok := r.URL.Query()["time"]
+	if !ok {
+		return time.Now(), true
+	}
+	timestamp, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		InvalidParam(w, "time")
+		return time.Time{}, false
+	}
+	return time.Unix(timestamp, 0), true
+}
+
+// DateRangeParams returns the campus days given by the from and to query
+// params, both "2006-01-02" and inclusive, as the midnight starting from and
+// the one ending to. They default to the week, Monday to Sunday, containing
+// today, and to to six days after from when only from is given. It replies 400
+// and returns false when either can't be parsed or the range is longer than
+// MaxDateRange days.
+func DateRangeParams(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	query := r.URL.Query()
+	from := schedule.Week(time.Now())
+	if value := query.Get("from"); value != "" {
+		day, err := schedule.ParseDate(value)
+		if err != nil {
+			InvalidParam(w, "from")
+			return time.Time{}, time.Time{}, false
+		}
+		from = day
+	}
+	to := from.AddDate(0, 0, 7)
+	if value := query.Get("to"); value != "" {
+		day, err := schedule.ParseDate(value)
+		if err != nil {
+			InvalidParam(w, "to")
+			return time.Time{}, time.Time{}, false
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+	if !to.After(from) || to.After(from.AddDate(0, 0, MaxDateRange)) {
+		WriteError(w, http.StatusBadRequest, Error{
+			Code:    CodeInvalidParam,
+			Param:   "to",
+			Message: fmt.Sprintf("Url Param 'to' must be on or after 'from' and at most %d days later", MaxDateRange-1),
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// NameParam returns the facility name from the {name} path segment, or from
+// the name query param when the endpoint is deployed on its own. It replies
+// 400 and returns false when neither is given.
+func NameParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("name")
+	if name == "" {
+		name = r.URL.Query().Get("name")
+	}
+	if name == "" {
+		MissingParam(w, "name")
+		return "", false
+	}
+	return name, true
+}
+
+// MaxLimit bounds the limit param of paged lists.
+const MaxLimit = 100
+
+// Page is the window of a list picked by the limit and offset query params.
+// A zero Limit takes every item after Offset.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// Bounds returns the start and end indexes of the page in a list of n items.
+func (p Page) Bounds(n int) (int, int) {
+	start := p.Offset
+	if start > n {
+		start = n
+	}
+	end := n
+	if p.Limit > 0 && start+p.Limit < n {
+		end = start + p.Limit
+	}
+	return start, end
+}
+
+// PageParams reads the optional limit and offset query params. It replies
+// 400 and returns false when limit isn't between 1 and MaxLimit or offset is
+// negative.
+func PageParams(w http.ResponseWriter, r *http.Request) (Page, bool) {
+	var page Page
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			InvalidParam(w, "limit")
+			return Page{}, false
+		}
+		page.Limit = limit
+	}
+	if value := r.URL.Query().Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			InvalidParam(w, "offset")
+			return Page{}, false
+		}
+		page.Offset = offset
+	}
+	return page, true
+}
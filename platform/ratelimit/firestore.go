@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Collection holds one document per bucket shard.
+const Collection = "rate_limits"
+
+// maxShards is how many documents a bucket is split across at most. Firestore
+// sustains about one write a second to a document, so a bucket kept in a
+// single one would throttle its caller well below most limits.
+const maxShards = 8
+
+// Firestore is a Backend sharing buckets between instances through
+// Firestore transactions. Each bucket is split into shards holding a share of
+// its burst and rate, and a request takes from a random shard, or from the
+// next one when that is empty. The caller is held to the limit overall, but
+// may be turned away while another shard still has requests left, and the
+// remaining count it is told is an estimate.
+type Firestore struct {
+	client *firestore.Client
+}
+
+// NewFirestore wraps client. Closing the backend closes the client.
+func NewFirestore(client *firestore.Client) *Firestore {
+	return &Firestore{client: client}
+}
+
+// firestoreBucket adds the time the bucket is full again, for a Firestore
+// TTL policy to delete it by.
+type firestoreBucket struct {
+	bucket
+	ExpiresAt time.Time `firestore:"expires_at"`
+}
+
+func (f *Firestore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	n := shards(limit)
+	first := rand.Intn(n)
+	var result Result
+	for try := 0; try < 2 && try < n; try++ {
+		i := (first + try) % n
+		shard := shardLimit(limit, n, i)
+		var err error
+		result, err = f.takeShard(ctx, fmt.Sprintf("%s-%d", url.PathEscape(key), i), shard, now)
+		if err != nil {
+			return result, err
+		}
+		// Scale the shard's count up to an estimate for the whole bucket.
+		result.Limit = limit.Burst
+		result.Remaining = min(limit.Burst, result.Remaining*n)
+		if result.Allowed {
+			break
+		}
+	}
+	return result, nil
+}
+
+// takeShard takes a request from the bucket shard stored under id.
+func (f *Firestore) takeShard(ctx context.Context, id string, limit Limit, now time.Time) (Result, error) {
+	doc := f.client.Collection(Collection).Doc(id)
+	var result Result
+	err := f.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var b firestoreBucket
+		snap, err := tx.Get(doc)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := snap.DataTo(&b); err != nil {
+				return err
+			}
+		}
+		result = b.take(limit, now)
+		b.ExpiresAt = now.Add(result.Reset)
+		return tx.Set(doc, b)
+	})
+	return result, err
+}
+
+// shards returns how many shards a bucket with limit is split across: up to
+// maxShards, but never so many that a shard holds no request.
+func shards(limit Limit) int {
+	return max(1, min(maxShards, limit.Burst))
+}
+
+// shardLimit returns the limit of shard i of n: an even share of the burst,
+// the first shards taking one more request each when it doesn't divide, and
+// of the rate in proportion, so every shard refills in the same time.
+func shardLimit(limit Limit, n, i int) Limit {
+	burst := limit.Burst / n
+	if i < limit.Burst%n {
+		burst++
+	}
+	rate := limit.Rate / float64(n)
+	if limit.Burst > 0 {
+		rate = limit.Rate * float64(burst) / float64(limit.Burst)
+	}
+	return Limit{Rate: rate, Burst: burst}
+}
+
+func (f *Firestore) Close() error {
+	return f.client.Close()
+}
@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShardLimit(t *testing.T) {
+	tests := []struct {
+		limit  Limit
+		shards int
+	}{
+		{PerMinute(600), maxShards},
+		{PerMinute(61), maxShards},
+		{PerMinute(5), 5},
+		{PerMinute(1), 1},
+		{Limit{Rate: 1, Burst: 0}, 1},
+	}
+	for _, test := range tests {
+		n := shards(test.limit)
+		if n != test.shards {
+			t.Errorf("shards(%+v) = %d, want %d", test.limit, n, test.shards)
+			continue
+		}
+		burst, rate := 0, 0.0
+		for i := 0; i < n; i++ {
+			shard := shardLimit(test.limit, n, i)
+			if test.limit.Burst > 0 && shard.Burst == 0 {
+				t.Errorf("shard %d of %+v holds no request", i, test.limit)
+			}
+			burst += shard.Burst
+			rate += shard.Rate
+		}
+		if burst != test.limit.Burst || math.Abs(rate-test.limit.Rate) > 1e-9 {
+			t.Errorf("shards of %+v add up to burst %d and rate %v", test.limit, burst, rate)
+		}
+	}
+}
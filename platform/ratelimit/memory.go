@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepSize is how many buckets Memory holds before it drops full ones.
+const sweepSize = 10000
+
+// Memory is a Backend keeping buckets in process memory. Each instance of a
+// function counts on its own.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+type memoryBucket struct {
+	bucket
+	limit Limit
+}
+
+// NewMemory returns an empty in-memory backend.
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*memoryBucket)}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.buckets[key]
+	if !ok {
+		if len(m.buckets) >= sweepSize {
+			m.sweep(now)
+		}
+		b = &memoryBucket{}
+		m.buckets[key] = b
+	}
+	b.limit = limit
+	return b.take(limit, now), nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if b.full(b.limit, now) {
+			delete(m.buckets, key)
+		}
+	}
+}
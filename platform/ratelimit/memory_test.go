@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryTake(t *testing.T) {
+	m := NewMemory()
+	limit := Limit{Rate: 1, Burst: 3}
+	start := time.Unix(1792300000, 0)
+	tests := []struct {
+		key        string
+		after      time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"a", 0, true, 2, 0, time.Second},
+		{"a", 0, true, 1, 0, 2 * time.Second},
+		{"a", 0, true, 0, 0, 3 * time.Second},
+		{"a", 0, false, 0, time.Second, 3 * time.Second},
+		// Other keys have buckets of their own.
+		{"b", 0, true, 2, 0, time.Second},
+		{"a", 500 * time.Millisecond, false, 0, 500 * time.Millisecond, 2500 * time.Millisecond},
+		{"a", time.Second, true, 0, 0, 3 * time.Second},
+		// A bucket never holds more than its burst.
+		{"a", time.Hour, true, 2, 0, time.Second},
+	}
+	for i, test := range tests {
+		got, err := m.Take(context.Background(), test.key, limit, start.Add(test.after))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Result{
+			Allowed:    test.allowed,
+			Limit:      limit.Burst,
+			Remaining:  test.remaining,
+			Reset:      test.reset,
+			RetryAfter: test.retryAfter,
+		}
+		if got != want {
+			t.Errorf("take %d (%s at +%v) = %+v, want %+v", i, test.key, test.after, got, want)
+		}
+	}
+}
+
+func TestPerMinute(t *testing.T) {
+	if got, want := PerMinute(60), (Limit{Rate: 1, Burst: 60}); got != want {
+		t.Errorf("PerMinute(60) = %+v, want %+v", got, want)
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	m := NewMemory()
+	limit := Limit{Rate: 1, Burst: 2}
+	start := time.Unix(1792300000, 0)
+	ctx := context.Background()
+	m.Take(ctx, "idle", limit, start)
+	m.Take(ctx, "busy", limit, start.Add(time.Minute))
+	m.Take(ctx, "busy", limit, start.Add(time.Minute))
+
+	m.sweep(start.Add(time.Minute))
+	if _, ok := m.buckets["idle"]; ok {
+		t.Error("sweep kept a bucket that has refilled")
+	}
+	if _, ok := m.buckets["busy"]; !ok {
+		t.Error("sweep dropped a bucket that hasn't refilled")
+	}
+}
@@ -0,0 +1,82 @@
+// Package ratelimit implements token bucket rate limiting over a pluggable
+// backend, in memory for a single instance or in Firestore to share buckets
+// between instances.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit configures a bucket holding up to Burst requests that refills at
+// Rate requests per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a limit of n requests a minute with a burst of n.
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// Result is the outcome of taking a request from a bucket.
+type Result struct {
+	Allowed bool
+	// Limit is the bucket size and Remaining the requests left in it.
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again and RetryAfter how
+	// long until the next request is allowed, zero when it already is.
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Backend stores buckets.
+type Backend interface {
+	// Take takes one request from the bucket named key at time now.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Close releases any connection held by the backend.
+	Close() error
+}
+
+// bucket is the stored state of a token bucket.
+type bucket struct {
+	Tokens  float64 `firestore:"tokens"`
+	Updated int64   `firestore:"updated"`
+}
+
+// take refills the bucket for the time elapsed since it was last updated and
+// takes a request from it if one is available.
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	burst := float64(limit.Burst)
+	if b.Updated == 0 {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(time.Unix(0, b.Updated)).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*limit.Rate)
+	}
+	b.Updated = now.UnixNano()
+
+	result := Result{Limit: limit.Burst}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.Tokens) / limit.Rate)
+	}
+	result.Remaining = int(b.Tokens)
+	result.Reset = seconds((burst - b.Tokens) / limit.Rate)
+	return result
+}
+
+// full reports whether the bucket would be full by now, so forgetting it
+// changes nothing.
+func (b *bucket) full(limit Limit, now time.Time) bool {
+	elapsed := now.Sub(time.Unix(0, b.Updated)).Seconds()
+	return b.Tokens+elapsed*limit.Rate >= float64(limit.Burst)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,114 @@
+package platform
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// Reasons a refresh token is rejected once its signature checks out.
+var (
+	ErrRevokedToken = invalidToken("token has been revoked")
+	ErrReusedToken  = invalidToken("refresh token was already used; every token issued since the last login has been revoked")
+)
+
+// StartRefreshFamily begins a new refresh token family for user granting
+// scopes, revoking any earlier one, and returns its first token. The caller
+// saves user.
+func StartRefreshFamily(ctx context.Context, user *users.User, scopes []string) (string, error) {
+	family, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	user.RefreshFamily = family
+	return rotate(ctx, user, scopes)
+}
+
+// RotateRefreshToken exchanges the refresh token described by claims for a
+// new one in the same family and returns it with the scopes it grants, less
+// any the user may no longer be granted. Presenting a token that was already
+// exchanged revokes the whole family and fails with ErrReusedToken.
+func RotateRefreshToken(ctx context.Context, db users.Store, claims *Claims) (string, []string, error) {
+	var token string
+	var scopes []string
+	reused := false
+	err := db.Update(ctx, claims.UID, func(user *users.User) error {
+		if user.Blocked {
+			return ErrAccountBlocked
+		}
+		if user.RefreshFamily == "" || user.RefreshFamily != claims.Family {
+			return ErrRevokedToken
+		}
+		if user.RefreshTokenID != claims.Id {
+			user.RevokeRefreshTokens()
+			reused = true
+			return nil
+		}
+		scopes = IntersectScopes(ParseScope(claims.Scope), AllowedScopes(user))
+		user.LastActiveAt = time.Now().Unix()
+		var err error
+		token, err = rotate(ctx, user, scopes)
+		return err
+	})
+	if err == users.ErrNotFound {
+		return "", nil, ErrRevokedToken
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if reused {
+		return "", nil, ErrReusedToken
+	}
+	return token, scopes, nil
+}
+
+// RevokeRefreshToken revokes the family of the refresh token described by
+// claims. Revoking a token that is no longer current is not an error.
+func RevokeRefreshToken(ctx context.Context, db users.Store, claims *Claims) error {
+	err := db.Update(ctx, claims.UID, func(user *users.User) error {
+		if user.RefreshFamily == claims.Family {
+			user.RevokeRefreshTokens()
+		}
+		return nil
+	})
+	if err == users.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// rotate signs the next token of user's current family and records it as
+// the only one accepted.
+func rotate(ctx context.Context, user *users.User, scopes []string) (string, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	token, err := NewToken(ctx, Claims{
+		StandardClaims: jwt.StandardClaims{Id: id},
+		UID:            user.UID,
+		Type:           TokenTypeRefresh,
+		Scope:          strings.Join(scopes, " "),
+		Family:         user.RefreshFamily,
+	}, RefreshTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	user.RefreshTokenID = id
+	user.RefreshExpiresAt = time.Now().Add(RefreshTokenTTL).Unix()
+	return token, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
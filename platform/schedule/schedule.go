@@ -0,0 +1,299 @@
+// Package schedule evaluates facility opening hours in campus time
+// (America/Los_Angeles). A schedule combines three sources, in order of
+// precedence for any given day:
+//
+//  1. Exceptions: hours for one date, such as a special event or a closure.
+//  2. Dated intervals: the open_close_array of a facility document.
+//  3. Weekly hours, recurring every week, unless the day is a campus holiday.
+//
+// Times are given as "15:04" in campus time. A closing time at or before the
+// opening time, such as "24:00" or "02:00", closes the next day.
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // campus time must resolve without a system zoneinfo
+)
+
+// DateLayout formats the dates of exceptions and holidays.
+const DateLayout = "2006-01-02"
+
+// Lookahead bounds how far past a time StatusAt looks for the next opening
+// or closing.
+const Lookahead = 14 * 24 * time.Hour
+
+// Location is campus time.
+var Location = mustLoadLocation("America/Los_Angeles")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Span is an opening from Open to Close on a day, both "15:04".
+type Span struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// Weekly maps lowercase weekday names, e.g. "monday", to that day's spans.
+// Days left out are closed.
+type Weekly map[string][]Span
+
+// Exception replaces a facility's hours on Date with Hours. An exception
+// without hours closes the facility all day.
+type Exception struct {
+	Date  string `json:"date"`
+	Hours []Span `json:"hours,omitempty"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// Holiday closes every facility on Date unless its dated intervals or an
+// exception say otherwise.
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// Period is a concrete interval of a schedule. Closed periods mark a
+// facility closed even where an open period overlaps them.
+type Period struct {
+	Open   time.Time
+	Close  time.Time
+	Notes  string
+	Closed bool
+}
+
+// Contains reports whether t falls in [Open, Close).
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Open) && t.Before(p.Close)
+}
+
+// Status is whether a schedule is open at some time and when it next opens
+// and closes after it. Opens and Closes are zero when that is more than
+// Lookahead away.
+type Status struct {
+	Open   bool
+	Opens  time.Time
+	Closes time.Time
+}
+
+// Change returns when Open next changes, or zero.
+func (st Status) Change() time.Time {
+	if st.Open {
+		return st.Closes
+	}
+	return st.Opens
+}
+
+// Schedule is the opening hours of one facility.
+type Schedule struct {
+	Intervals  []Period
+	Weekly     Weekly
+	Exceptions []Exception
+	Holidays   []Holiday
+}
+
+// Day returns the local midnight starting the campus day containing t.
+func Day(t time.Time) time.Time {
+	y, m, d := t.In(Location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, Location)
+}
+
+// Week returns the local midnight starting the Monday-to-Sunday campus week
+// containing t.
+func Week(t time.Time) time.Time {
+	day := Day(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// ParseDate parses a "2006-01-02" date as a campus day.
+func ParseDate(s string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, s, Location)
+}
+
+// DayPeriods returns the periods of the campus day starting at day, which
+// must be a local midnight as returned by Day.
+func (s Schedule) DayPeriods(day time.Time) []Period {
+	date := day.Format(DateLayout)
+	for _, ex := range s.Exceptions {
+		if ex.Date != date {
+			continue
+		}
+		if len(ex.Hours) == 0 {
+			return []Period{{Open: day, Close: day.AddDate(0, 0, 1), Notes: ex.Notes, Closed: true}}
+		}
+		return spanPeriods(day, ex.Hours, ex.Notes)
+	}
+	var dated []Period
+	for _, p := range s.Intervals {
+		if p.Open.In(Location).Format(DateLayout) == date {
+			dated = append(dated, p)
+		}
+	}
+	if len(dated) > 0 {
+		return dated
+	}
+	for _, h := range s.Holidays {
+		if h.Date == date {
+			return []Period{{Open: day, Close: day.AddDate(0, 0, 1), Notes: h.Name, Closed: true}}
+		}
+	}
+	return spanPeriods(day, s.Weekly[strings.ToLower(day.Weekday().String())], "")
+}
+
+// Periods returns the periods overlapping [from, to), in order of opening.
+func (s Schedule) Periods(from, to time.Time) []Period {
+	var out []Period
+	// Start a day early for spans running past midnight.
+	for day := Day(from).AddDate(0, 0, -1); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, p := range s.DayPeriods(day) {
+			if p.Close.After(from) && p.Open.Before(to) {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// OpenAt reports whether an open period contains t and no closed one does.
+func (s Schedule) OpenAt(t time.Time) bool {
+	return openAt(s.Periods(t, t.Add(time.Second)), t)
+}
+
+// StatusAt returns the status of the schedule at t. Back-to-back and
+// overlapping periods count as one opening.
+func (s Schedule) StatusAt(t time.Time) Status {
+	end := t.Add(Lookahead)
+	periods := s.Periods(t, end)
+	status := Status{Open: openAt(periods, t)}
+
+	// Whether the schedule is open only changes where a period starts or ends.
+	var bounds []time.Time
+	for _, p := range periods {
+		for _, b := range []time.Time{p.Open, p.Close} {
+			if b.After(t) && b.Before(end) {
+				bounds = append(bounds, b)
+			}
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+
+	open := status.Open
+	for _, b := range bounds {
+		if openAt(periods, b) == open {
+			continue
+		}
+		open = !open
+		if open && status.Opens.IsZero() {
+			status.Opens = b
+		} else if !open && status.Closes.IsZero() {
+			status.Closes = b
+		}
+		if !status.Opens.IsZero() && !status.Closes.IsZero() {
+			break
+		}
+	}
+	return status
+}
+
+func openAt(periods []Period, t time.Time) bool {
+	open := false
+	for _, p := range periods {
+		if p.Contains(t) {
+			if p.Closed {
+				return false
+			}
+			open = true
+		}
+	}
+	return open
+}
+
+func spanPeriods(day time.Time, spans []Span, notes string) []Period {
+	periods := make([]Period, 0, len(spans))
+	for _, span := range spans {
+		open, err := parseClock(span.Open)
+		if err != nil {
+			continue
+		}
+		closes, err := parseClock(span.Close)
+		if err != nil {
+			continue
+		}
+		if closes <= open {
+			closes += 24 * 60
+		}
+		y, m, d := day.Date()
+		periods = append(periods, Period{
+			Open:  time.Date(y, m, d, 0, open, 0, 0, Location),
+			Close: time.Date(y, m, d, 0, closes, 0, 0, Location),
+			Notes: notes,
+		})
+	}
+	return periods
+}
+
+// parseClock returns the minutes since midnight of a "15:04" time. "24:00"
+// is accepted as the end of the day.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%2d:%2d", &h, &m); err != nil || len(s) != 5 {
+		return 0, fmt.Errorf("time %q isn't formatted as 15:04", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("time %q is out of range", s)
+	}
+	return h*60 + m, nil
+}
+
+// Validate checks the day names and times of the weekly hours.
+func (w Weekly) Validate() error {
+	for day, spans := range w {
+		if !isWeekday(day) {
+			return fmt.Errorf("unknown weekday %q", day)
+		}
+		if err := validateSpans(spans); err != nil {
+			return fmt.Errorf("%s: %v", day, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the exception's date and times.
+func (e Exception) Validate() error {
+	if _, err := ParseDate(e.Date); err != nil {
+		return fmt.Errorf("exception date %q isn't formatted as %s", e.Date, DateLayout)
+	}
+	if err := validateSpans(e.Hours); err != nil {
+		return fmt.Errorf("exception %s: %v", e.Date, err)
+	}
+	return nil
+}
+
+func validateSpans(spans []Span) error {
+	for _, span := range spans {
+		if _, err := parseClock(span.Open); err != nil {
+			return err
+		}
+		if _, err := parseClock(span.Close); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isWeekday(name string) bool {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if name == strings.ToLower(d.String()) {
+			return true
+		}
+	}
+	return false
+}
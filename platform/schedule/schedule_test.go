@@ -0,0 +1,155 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at parses a "2006-01-02 15:04" campus time.
+func at(t *testing.T, s string) time.Time {
+	t.Helper()
+	tm, err := time.ParseInLocation("2006-01-02 15:04", s, Location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+func TestDayPeriodsDST(t *testing.T) {
+	allDay := []Span{{"00:00", "24:00"}}
+	s := Schedule{Weekly: Weekly{"saturday": {{"08:00", "20:00"}}, "sunday": allDay}}
+	tests := []struct {
+		day     string
+		openUTC string
+		length  time.Duration
+	}{
+		// Daylight saving time ends on Sunday, November 1, 2026, and
+		// starts on Sunday, March 8.
+		{"2026-10-31", "2026-10-31 15:00", 12 * time.Hour},
+		{"2026-11-01", "2026-11-01 07:00", 25 * time.Hour},
+		{"2026-11-07", "2026-11-07 16:00", 12 * time.Hour},
+		{"2026-03-08", "2026-03-08 08:00", 23 * time.Hour},
+	}
+	for _, test := range tests {
+		day, err := ParseDate(test.day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		periods := s.DayPeriods(day)
+		if len(periods) != 1 {
+			t.Fatalf("%s: %d periods, want 1", test.day, len(periods))
+		}
+		p := periods[0]
+		if got := p.Open.UTC().Format("2006-01-02 15:04"); got != test.openUTC {
+			t.Errorf("%s: opens %s UTC, want %s", test.day, got, test.openUTC)
+		}
+		if got := p.Close.Sub(p.Open); got != test.length {
+			t.Errorf("%s: open for %v, want %v", test.day, got, test.length)
+		}
+	}
+}
+
+func TestStatusAt(t *testing.T) {
+	weekly := Weekly{
+		"monday":   {{"08:00", "12:00"}, {"12:00", "18:00"}},
+		"tuesday":  {{"08:00", "18:00"}},
+		"friday":   {{"20:00", "02:00"}},
+		"saturday": {{"10:00", "14:00"}},
+	}
+	holidays := []Holiday{{Date: "2026-11-23", Name: "Campus closure"}, {Date: "2026-11-24", Name: "Campus closure"}}
+	tests := []struct {
+		name     string
+		schedule Schedule
+		t        string
+		open     bool
+		opens    string
+		closes   string
+	}{
+		{"weekly hours", Schedule{Weekly: weekly}, "2026-10-20 09:00", true, "2026-10-23 20:00", "2026-10-20 18:00"},
+		{"before opening", Schedule{Weekly: weekly}, "2026-10-20 07:00", false, "2026-10-20 08:00", "2026-10-20 18:00"},
+		{"back-to-back spans are one opening", Schedule{Weekly: weekly}, "2026-10-19 11:00", true, "2026-10-20 08:00", "2026-10-19 18:00"},
+		{"overnight span", Schedule{Weekly: weekly}, "2026-10-24 01:00", true, "2026-10-24 10:00", "2026-10-24 02:00"},
+		{"holiday", Schedule{Weekly: weekly, Holidays: holidays}, "2026-11-23 09:00", false, "2026-11-27 20:00", "2026-11-28 02:00"},
+		{"dated intervals beat a holiday", Schedule{
+			Weekly:    weekly,
+			Holidays:  holidays,
+			Intervals: []Period{{Open: at(t, "2026-11-23 10:00"), Close: at(t, "2026-11-23 12:00")}},
+		}, "2026-11-23 11:00", true, "2026-11-27 20:00", "2026-11-23 12:00"},
+		{"exception hours replace a holiday", Schedule{
+			Weekly:     weekly,
+			Holidays:   holidays,
+			Exceptions: []Exception{{Date: "2026-11-24", Hours: []Span{{"12:00", "13:00"}}}},
+		}, "2026-11-24 09:00", false, "2026-11-24 12:00", "2026-11-24 13:00"},
+		{"exception hours replace weekly hours", Schedule{
+			Weekly:     weekly,
+			Exceptions: []Exception{{Date: "2026-10-20", Hours: []Span{{"10:00", "11:00"}}}},
+		}, "2026-10-20 09:00", false, "2026-10-20 10:00", "2026-10-20 11:00"},
+		{"closed exception", Schedule{
+			Weekly:     weekly,
+			Exceptions: []Exception{{Date: "2026-10-20", Notes: "Maintenance"}},
+		}, "2026-10-20 09:00", false, "2026-10-23 20:00", "2026-10-24 02:00"},
+		{"closed exception cuts off an overnight span", Schedule{
+			Weekly:     weekly,
+			Exceptions: []Exception{{Date: "2026-10-24"}},
+		}, "2026-10-23 23:00", true, "2026-10-26 08:00", "2026-10-24 00:00"},
+		{"nothing within the lookahead", Schedule{}, "2026-10-20 09:00", false, "", ""},
+	}
+	for _, test := range tests {
+		got := test.schedule.StatusAt(at(t, test.t))
+		if got.Open != test.open {
+			t.Errorf("%s: open = %v, want %v", test.name, got.Open, test.open)
+		}
+		for _, c := range []struct {
+			field string
+			got   time.Time
+			want  string
+		}{{"opens", got.Opens, test.opens}, {"closes", got.Closes, test.closes}} {
+			if c.want == "" {
+				if !c.got.IsZero() {
+					t.Errorf("%s: %s %v, want none", test.name, c.field, c.got)
+				}
+				continue
+			}
+			if !c.got.Equal(at(t, c.want)) {
+				t.Errorf("%s: %s %v, want %s", test.name, c.field, c.got.In(Location), c.want)
+			}
+		}
+	}
+}
+
+func TestDayPeriodsNotes(t *testing.T) {
+	s := Schedule{
+		Weekly:     Weekly{"monday": {{"08:00", "18:00"}}},
+		Exceptions: []Exception{{Date: "2026-10-19", Notes: "Maintenance"}},
+		Holidays:   []Holiday{{Date: "2026-10-26", Name: "Campus closure"}},
+	}
+	for date, want := range map[string]string{"2026-10-19": "Maintenance", "2026-10-26": "Campus closure"} {
+		day, _ := ParseDate(date)
+		periods := s.DayPeriods(day)
+		if len(periods) != 1 || !periods[0].Closed || periods[0].Notes != want {
+			t.Errorf("%s: periods %+v, want one closed period noted %q", date, periods, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		valid bool
+	}{
+		{"weekly hours", Weekly{"monday": {{"08:00", "24:00"}}}.Validate(), true},
+		{"unknown day", Weekly{"Monday": {{"08:00", "18:00"}}}.Validate(), false},
+		{"bad time", Weekly{"monday": {{"8:00", "18:00"}}}.Validate(), false},
+		{"past midnight", Weekly{"monday": {{"08:00", "24:30"}}}.Validate(), false},
+		{"exception", Exception{Date: "2026-10-19", Hours: []Span{{"10:00", "11:00"}}}.Validate(), true},
+		{"closed exception", Exception{Date: "2026-10-19"}.Validate(), true},
+		{"bad exception date", Exception{Date: "10/19/2026"}.Validate(), false},
+		{"bad exception time", Exception{Date: "2026-10-19", Hours: []Span{{"10:00", "11:60"}}}.Validate(), false},
+	}
+	for _, test := range tests {
+		if (test.err == nil) != test.valid {
+			t.Errorf("%s: error = %v, want valid %v", test.name, test.err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package platform
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// Scopes a token or API key can be granted. Each endpoint requires one.
+const (
+	ScopeGymsRead      = "gyms:read"
+	ScopeLibrariesRead = "libraries:read"
+	ScopeDiningRead    = "dining:read"
+	ScopeResourcesRead = "resources:read"
+	ScopeTransitRead   = "transit:read"
+	ScopeWeatherRead   = "weather:read"
+	ScopeAdminRead     = "admin:read"
+	ScopeAdminWrite    = "admin:write"
+)
+
+// CodeInsufficientScope is returned when valid credentials lack a scope the
+// endpoint requires.
+const CodeInsufficientScope = "insufficient_scope"
+
+// DefaultScopes are granted when a login or API key doesn't ask for any.
+var DefaultScopes = []string{
+	ScopeGymsRead,
+	ScopeLibrariesRead,
+	ScopeDiningRead,
+	ScopeResourcesRead,
+	ScopeTransitRead,
+	ScopeWeatherRead,
+}
+
+var adminScopes = []string{ScopeAdminRead, ScopeAdminWrite}
+
+// AllowedScopes returns the scopes user may be granted. Admin scopes are only
+// granted on request, never by default.
+func AllowedScopes(user *users.User) []string {
+	allowed := append([]string(nil), DefaultScopes...)
+	if user.Admin {
+		allowed = append(allowed, adminScopes...)
+	}
+	return allowed
+}
+
+// ParseScope splits a space-delimited scope string as sent in OAuth requests.
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// GrantScopes checks the requested scopes against the allowed ones and
+// returns the scopes to grant, DefaultScopes when none are requested. It
+// returns the first scope that can't be granted when there is one.
+func GrantScopes(requested, allowed []string) ([]string, string) {
+	if len(requested) == 0 {
+		requested = DefaultScopes
+	}
+	granted := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if !containsScope(allowed, scope) {
+			return nil, scope
+		}
+		if !containsScope(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	return granted, ""
+}
+
+// IntersectScopes returns the scopes in a that are also in b.
+func IntersectScopes(a, b []string) []string {
+	out := make([]string, 0, len(a))
+	for _, scope := range a {
+		if containsScope(b, scope) && !containsScope(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	return out
+}
+
+// HasScope reports whether the claims grant scope.
+func (c *Claims) HasScope(scope string) bool {
+	return containsScope(ParseScope(c.Scope), scope)
+}
+
+// InsufficientScope replies with 403 naming the scopes the endpoint requires.
+func InsufficientScope(w http.ResponseWriter, scopes ...string) {
+	required := strings.Join(scopes, " ")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="octoapi", error="insufficient_scope", scope="`+required+`"`)
+	WriteError(w, http.StatusForbidden, Error{
+		Code:    CodeInsufficientScope,
+		Message: "Your token doesn't grant the " + required + " scope this endpoint requires. Request it when logging in or creating an API key.",
+	})
+}
+
+// InvalidScope replies that scope can't be granted.
+func InvalidScope(w http.ResponseWriter, scope string) {
+	WriteError(w, http.StatusBadRequest, Error{
+		Code:    CodeInvalidParam,
+		Param:   "scope",
+		Message: "Scope '" + scope + "' is unknown or not available to your account",
+	})
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
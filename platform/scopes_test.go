@@ -0,0 +1,67 @@
+package platform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  []string
+	}{
+		{"", []string{}},
+		{"   ", []string{}},
+		{"gyms:read", []string{"gyms:read"}},
+		{"gyms:read dining:read", []string{"gyms:read", "dining:read"}},
+		{" gyms:read\tdining:read\n", []string{"gyms:read", "dining:read"}},
+	}
+	for _, test := range tests {
+		if got := ParseScope(test.scope); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseScope(%q) = %q, want %q", test.scope, got, test.want)
+		}
+	}
+}
+
+func TestIntersectScopes(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want []string
+	}{
+		{nil, nil, []string{}},
+		{[]string{"gyms:read"}, nil, []string{}},
+		{nil, []string{"gyms:read"}, []string{}},
+		{[]string{"gyms:read", "admin:write"}, DefaultScopes, []string{"gyms:read"}},
+		// Order follows a; duplicates are dropped.
+		{[]string{"weather:read", "gyms:read", "weather:read"}, []string{"gyms:read", "weather:read"}, []string{"weather:read", "gyms:read"}},
+		{[]string{"Gyms:Read"}, []string{"gyms:read"}, []string{}},
+	}
+	for _, test := range tests {
+		if got := IntersectScopes(test.a, test.b); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("IntersectScopes(%q, %q) = %q, want %q", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestGrantScopes(t *testing.T) {
+	admin := AllowedScopes(&users.User{Admin: true})
+	tests := []struct {
+		requested, allowed []string
+		want               []string
+		denied             string
+	}{
+		{nil, DefaultScopes, DefaultScopes, ""},
+		{[]string{"gyms:read", "gyms:read"}, DefaultScopes, []string{"gyms:read"}, ""},
+		{[]string{"gyms:read", "admin:read"}, DefaultScopes, nil, "admin:read"},
+		{[]string{"admin:read"}, admin, []string{"admin:read"}, ""},
+		{[]string{"nope:read"}, admin, nil, "nope:read"},
+	}
+	for _, test := range tests {
+		got, denied := GrantScopes(test.requested, test.allowed)
+		if !reflect.DeepEqual(got, test.want) || denied != test.denied {
+			t.Errorf("GrantScopes(%q, %q) = %q, %q; want %q, %q", test.requested, test.allowed, got, denied, test.want, test.denied)
+		}
+	}
+}
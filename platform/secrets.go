@@ -0,0 +1,98 @@
+// Package platform holds the code shared by every OCTO API endpoint: secret
+// loading, Firestore clients, access token validation and CORS headers.
+package platform
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+)
+
+// Names of the secrets stored in Secret Manager.
+const (
+	FirestoreKeySecret = "firestore_access_key"
+	JwtKeySecret       = "jwt_encryption_key"
+	TransitKeySecret   = "transit_api_key"
+	WeatherKeySecret   = "weather_access_key"
+	SendGridKeySecret  = "sendgrid_key"
+
+	// SigningKeysSecret holds the private keys the auth endpoints sign
+	// tokens with, PublicKeysSecret the JWKS every endpoint verifies them
+	// against. Both are read at their latest version so keys can be rolled.
+	SigningKeysSecret = "jwt_signing_keys"
+	PublicKeysSecret  = "jwt_public_keys"
+)
+
+var secretProject = "projects/980046983693"
+
+var secretCache = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// Secret returns the value of the named secret. The environment
+// variable OCTOAPI_SECRET_<NAME> takes precedence over Secret Manager so the
+// endpoints can run locally without GCP credentials.
+func Secret(ctx context.Context, name string) (string, error) {
+	if value, ok := os.LookupEnv(secretEnvName(name)); ok {
+		return value, nil
+	}
+	secretCache.Lock()
+	value, ok := secretCache.values[name]
+	secretCache.Unlock()
+	if ok {
+		return value, nil
+	}
+	value, err := accessSecret(ctx, name, "1")
+	if err != nil {
+		return "", err
+	}
+	secretCache.Lock()
+	secretCache.values[name] = value
+	secretCache.Unlock()
+	return value, nil
+}
+
+// JwtSecret returns the HMAC key used to sign and verify tokens.
+func JwtSecret(ctx context.Context) ([]byte, error) {
+	key, err := Secret(ctx, JwtKeySecret)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(key), nil
+}
+
+// LatestSecret returns the latest version of the named secret, bypassing the
+// cache. The OCTOAPI_SECRET_<NAME> override applies as for Secret.
+func LatestSecret(ctx context.Context, name string) (string, error) {
+	if value, ok := os.LookupEnv(secretEnvName(name)); ok {
+		return value, nil
+	}
+	return accessSecret(ctx, name, "latest")
+}
+
+func accessSecret(ctx context.Context, name string, version string) (string, error) {
+	client, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	// Build the request.
+	req := &secretmanagerpb.AccessSecretVersionRequest{
+		Name: secretProject + "/secrets/" + name + "/versions/" + version,
+	}
+	// Call the API.
+	result, err := client.AccessSecretVersion(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return string(result.Payload.Data), nil
+}
+
+func secretEnvName(name string) string {
+	return "OCTOAPI_SECRET_" + strings.ToUpper(name)
+}
@@ -0,0 +1,43 @@
+package platform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSecretEnvOverride(t *testing.T) {
+	t.Setenv("OCTOAPI_SECRET_TRANSIT_API_KEY", "from-env")
+	// Set but empty still wins, so a local run never reaches Secret Manager.
+	t.Setenv("OCTOAPI_SECRET_WEATHER_ACCESS_KEY", "")
+	tests := []struct {
+		name string
+		want string
+	}{
+		{TransitKeySecret, "from-env"},
+		{WeatherKeySecret, ""},
+	}
+	for _, test := range tests {
+		for fn, get := range map[string]func(context.Context, string) (string, error){
+			"Secret":       Secret,
+			"LatestSecret": LatestSecret,
+		} {
+			got, err := get(context.Background(), test.name)
+			if err != nil || got != test.want {
+				t.Errorf("%s(%s) = %q, %v; want %q", fn, test.name, got, err, test.want)
+			}
+		}
+	}
+}
+
+func TestSecretEnvName(t *testing.T) {
+	tests := map[string]string{
+		JwtKeySecret:      "OCTOAPI_SECRET_JWT_ENCRYPTION_KEY",
+		SigningKeysSecret: "OCTOAPI_SECRET_JWT_SIGNING_KEYS",
+		SendGridKeySecret: "OCTOAPI_SECRET_SENDGRID_KEY",
+	}
+	for name, want := range tests {
+		if got := secretEnvName(name); got != want {
+			t.Errorf("secretEnvName(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
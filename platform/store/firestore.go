@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// indexCheckTTL is how long an instance trusts that every document of a
+// collection has an up-to-date geohash before reading it all to check again.
+const indexCheckTTL = 10 * time.Minute
+
+// indexedUntil records, per collection, until when Near and Nearest may rely
+// on geohash range queries.
+var indexedUntil = struct {
+	mu          sync.Mutex
+	collections map[string]time.Time
+}{collections: make(map[string]time.Time)}
+
+// Firestore is a Store backed by a Firestore client.
+type Firestore struct {
+	client *firestore.Client
+}
+
+// NewFirestore wraps client. Closing the store closes the client.
+func NewFirestore(client *firestore.Client) *Firestore {
+	return &Firestore{client: client}
+}
+
+func (s *Firestore) List(ctx context.Context, collection string) ([]Document, error) {
+	return readAll(s.client.Collection(collection).Documents(ctx))
+}
+
+func (s *Firestore) GetByName(ctx context.Context, collection string, name string) (Document, error) {
+	iter := s.client.Collection(collection).Where("name", "==", name).Limit(1).Documents(ctx)
+	defer iter.Stop()
+	doc, err := iter.Next()
+	if err == iterator.Done {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Data(), nil
+}
+
+func (s *Firestore) Query(ctx context.Context, collection string, field string, value interface{}) ([]Document, error) {
+	return readAll(s.client.Collection(collection).Where(field, "==", value).Documents(ctx))
+}
+
+func (s *Firestore) Near(ctx context.Context, collection string, latitude, longitude, radiusKm float64) ([]Document, error) {
+	scan, err := s.scanFor(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return near(ctx, collection, latitude, longitude, radiusKm, scan)
+}
+
+func (s *Firestore) Nearest(ctx context.Context, collection string, latitude, longitude float64, k int) ([]Document, error) {
+	scan, err := s.scanFor(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return nearest(ctx, collection, latitude, longitude, k, scan)
+}
+
+// scanFor returns how to scan the collection by geohash prefix. Range queries
+// would miss documents written without a geohash, or moved since, so until
+// the collection has been read and found fully indexed, it is filtered in
+// memory with geohashes computed from each document's location.
+func (s *Firestore) scanFor(ctx context.Context, collection string) (scanFunc, error) {
+	indexedUntil.mu.Lock()
+	until := indexedUntil.collections[collection]
+	indexedUntil.mu.Unlock()
+	if time.Now().Before(until) {
+		return s.scanGeohash, nil
+	}
+	docs, err := s.List(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if setGeohashes(docs) {
+		indexedUntil.mu.Lock()
+		indexedUntil.collections[collection] = time.Now().Add(indexCheckTTL)
+		indexedUntil.mu.Unlock()
+	} else {
+		log.Printf("%s: documents without an up-to-date %s field, scanning the whole collection; run octoapi-geohash -write", collection, GeohashField)
+	}
+	return scanDocuments(docs), nil
+}
+
+// scanGeohash returns the documents whose geohash starts with prefix.
+func (s *Firestore) scanGeohash(ctx context.Context, collection, prefix string) ([]Document, error) {
+	// "~" sorts after every geohash character.
+	query := s.client.Collection(collection).
+		Where(GeohashField, ">=", prefix).
+		Where(GeohashField, "<=", prefix+"~")
+	return readAll(query.Documents(ctx))
+}
+
+// IndexGeohashes sets the GeohashField of every document in the collection
+// that has a location and whose geohash is missing or stale. It returns the
+// number of documents updated or, with dryRun, that would be, without
+// writing anything.
+func (s *Firestore) IndexGeohashes(ctx context.Context, collection string, dryRun bool) (int, error) {
+	iter := s.client.Collection(collection).Documents(ctx)
+	defer iter.Stop()
+	updated := 0
+	for {
+		snap, err := iter.Next()
+		if err == iterator.Done {
+			return updated, nil
+		}
+		if err != nil {
+			return updated, err
+		}
+		doc := Document(snap.Data())
+		hash, ok := Geohash(doc)
+		if !ok || doc[GeohashField] == hash {
+			continue
+		}
+		if dryRun {
+			updated++
+			continue
+		}
+		if _, err := snap.Ref.Update(ctx, []firestore.Update{{Path: GeohashField, Value: hash}}); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+}
+
+func (s *Firestore) Close() error {
+	return s.client.Close()
+}
+
+func readAll(iter *firestore.DocumentIterator) ([]Document, error) {
+	defer iter.Stop()
+	docs := make([]Document, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc.Data())
+	}
+	return docs, nil
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/umahmood/haversine"
+
+	"github.com/asuc-octo/octoapi/platform/geohash"
+)
+
+// GeohashField holds the geohash of a document's latitude and longitude
+// fields, which Near and Nearest scan by prefix.
+const GeohashField = "geohash"
+
+// nearestStartKm is the first radius Nearest searches; it doubles until
+// enough documents are found, for at most nearestMaxRounds radii.
+const (
+	nearestStartKm   = 0.5
+	nearestMaxRounds = 8
+)
+
+// maxSearchKm is half the Earth's circumference, a radius that covers every
+// point.
+const maxSearchKm = 20040
+
+// Location returns the document's latitude and longitude fields, and false
+// when either is missing or not a number.
+func Location(doc Document) (float64, float64, bool) {
+	latitude, latOk := toFloat(doc["latitude"])
+	longitude, lonOk := toFloat(doc["longitude"])
+	return latitude, longitude, latOk && lonOk
+}
+
+// Geohash returns the geohash for the document's location, and false when it
+// has none.
+func Geohash(doc Document) (string, bool) {
+	latitude, longitude, ok := Location(doc)
+	if !ok {
+		return "", false
+	}
+	return geohash.Encode(latitude, longitude, geohash.Precision), true
+}
+
+// distanceKm returns the great-circle distance from a point to the
+// document's location, and false when the document has none.
+func distanceKm(doc Document, latitude, longitude float64) (float64, bool) {
+	docLat, docLon, ok := Location(doc)
+	if !ok {
+		return 0, false
+	}
+	_, km := haversine.Distance(haversine.Coord{Lat: latitude, Lon: longitude}, haversine.Coord{Lat: docLat, Lon: docLon})
+	return km, true
+}
+
+// scanFunc returns the documents of a collection whose geohash starts with
+// prefix.
+type scanFunc func(ctx context.Context, collection, prefix string) ([]Document, error)
+
+// setGeohashes sets the GeohashField of each document that has a location and
+// reports whether every one of them already had it right.
+func setGeohashes(docs []Document) bool {
+	complete := true
+	for _, doc := range docs {
+		hash, ok := Geohash(doc)
+		if !ok {
+			continue
+		}
+		if doc[GeohashField] != hash {
+			doc[GeohashField] = hash
+			complete = false
+		}
+	}
+	return complete
+}
+
+// scanDocuments returns a scanFunc that filters docs, whose GeohashField must
+// be set, rather than reading from a store.
+func scanDocuments(docs []Document) scanFunc {
+	return func(ctx context.Context, collection, prefix string) ([]Document, error) {
+		out := make([]Document, 0)
+		for _, doc := range docs {
+			if hash, _ := doc[GeohashField].(string); strings.HasPrefix(hash, prefix) {
+				out = append(out, doc)
+			}
+		}
+		return out, nil
+	}
+}
+
+// scanner runs the geohash scans of one query, remembering what it has read
+// so that a prefix inside one already scanned is filtered from those
+// documents rather than read again.
+type scanner struct {
+	collection string
+	scan       scanFunc
+	scanned    map[string][]Document
+}
+
+func newScanner(collection string, scan scanFunc) *scanner {
+	return &scanner{collection: collection, scan: scan, scanned: make(map[string][]Document)}
+}
+
+// prefix returns the documents whose geohash starts with prefix.
+func (s *scanner) prefix(ctx context.Context, prefix string) ([]Document, error) {
+	for n := len(prefix); n >= 0; n-- {
+		outer, ok := s.scanned[prefix[:n]]
+		if !ok {
+			continue
+		}
+		if n == len(prefix) {
+			return outer, nil
+		}
+		docs := make([]Document, 0)
+		for _, doc := range outer {
+			if hash, _ := doc[GeohashField].(string); strings.HasPrefix(hash, prefix) {
+				docs = append(docs, doc)
+			}
+		}
+		s.scanned[prefix] = docs
+		return docs, nil
+	}
+	docs, err := s.scan(ctx, s.collection, prefix)
+	if err != nil {
+		return nil, err
+	}
+	s.scanned[prefix] = docs
+	return docs, nil
+}
+
+// near finds the documents within radiusKm of a point by scanning the
+// geohash prefixes covering the circle, or the whole collection when no
+// prefixes do, and returns them nearest first.
+func near(ctx context.Context, collection string, latitude, longitude, radiusKm float64, scan scanFunc) ([]Document, error) {
+	return newScanner(collection, scan).near(ctx, latitude, longitude, radiusKm)
+}
+
+func (s *scanner) near(ctx context.Context, latitude, longitude, radiusKm float64) ([]Document, error) {
+	prefixes := geohash.Cover(latitude, longitude, radiusKm)
+	if prefixes == nil {
+		prefixes = []string{""}
+	}
+	type match struct {
+		doc Document
+		km  float64
+	}
+	var matches []match
+	for _, prefix := range prefixes {
+		docs, err := s.prefix(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			if km, ok := distanceKm(doc, latitude, longitude); ok && km <= radiusKm {
+				matches = append(matches, match{doc, km})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].km < matches[j].km })
+	docs := make([]Document, 0, len(matches))
+	for _, m := range matches {
+		docs = append(docs, m.doc)
+	}
+	return docs, nil
+}
+
+// nearest runs radius queries of doubling radius until one finds k
+// documents, and returns the k nearest of them. Radii that share a cover
+// reuse its scans, and after nearestMaxRounds radii it reads the whole
+// collection once instead of widening further, so asking for more documents
+// than there are costs one full scan rather than a round per doubling.
+func nearest(ctx context.Context, collection string, latitude, longitude float64, k int, scan scanFunc) ([]Document, error) {
+	s := newScanner(collection, scan)
+	radiusKm := nearestStartKm
+	for round := 1; round < nearestMaxRounds; round++ {
+		docs, err := s.near(ctx, latitude, longitude, radiusKm)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) >= k {
+			return docs[:k], nil
+		}
+		if _, ok := s.scanned[""]; ok {
+			// The whole collection has been read.
+			return docs, nil
+		}
+		radiusKm *= 2
+	}
+	docs, err := s.near(ctx, latitude, longitude, maxSearchKm)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) > k {
+		docs = docs[:k]
+	}
+	return docs, nil
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+// places are a few documents around Berkeley, from nearest to farthest
+// from the origin used below.
+func places() map[string][]Document {
+	return map[string][]Document{"Places": {
+		{"name": "RSF", "latitude": 37.8686, "longitude": -122.2627},
+		{"name": "Oakland", "latitude": 37.8044, "longitude": -122.2712},
+		{"name": "San Francisco", "latitude": 37.7749, "longitude": -122.4194},
+		{"name": "Los Angeles", "latitude": 34.0522, "longitude": -118.2437},
+		{"name": "Nowhere"},
+	}}
+}
+
+const originLat, originLon = 37.8690, -122.2600
+
+func names(docs []Document) []string {
+	out := make([]string, len(docs))
+	for i, doc := range docs {
+		out[i], _ = doc["name"].(string)
+	}
+	return out
+}
+
+func TestNear(t *testing.T) {
+	m := NewMemory(places())
+	tests := []struct {
+		radiusKm float64
+		want     []string
+	}{
+		{0.1, []string{}},
+		{1, []string{"RSF"}},
+		{10, []string{"RSF", "Oakland"}},
+		{20, []string{"RSF", "Oakland", "San Francisco"}},
+		{1000, []string{"RSF", "Oakland", "San Francisco", "Los Angeles"}},
+		// Too wide for any cover: the whole collection is scanned.
+		{maxSearchKm, []string{"RSF", "Oakland", "San Francisco", "Los Angeles"}},
+	}
+	for _, test := range tests {
+		docs, err := m.Near(context.Background(), "Places", originLat, originLon, test.radiusKm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(docs); !equalStrings(got, test.want) {
+			t.Errorf("Near(%v km) = %v, want %v", test.radiusKm, got, test.want)
+		}
+	}
+}
+
+func TestNearUnindexed(t *testing.T) {
+	docs := places()["Places"]
+	// One stale geohash, as if the place had moved since it was indexed.
+	docs[0][GeohashField] = "9q9p"
+	if setGeohashes(docs) {
+		t.Fatal("setGeohashes reported documents without a geohash as complete")
+	}
+	if !setGeohashes(docs) {
+		t.Error("setGeohashes reported indexed documents as incomplete")
+	}
+	got, err := near(context.Background(), "Places", originLat, originLon, 20, scanDocuments(docs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"RSF", "Oakland", "San Francisco"}; !equalStrings(names(got), want) {
+		t.Errorf("near over unindexed documents = %v, want %v", names(got), want)
+	}
+}
+
+func TestNearAntimeridian(t *testing.T) {
+	m := NewMemory(map[string][]Document{"Places": {
+		{"name": "East", "latitude": -17.7, "longitude": 179.99},
+		{"name": "West", "latitude": -17.7, "longitude": -179.99},
+	}})
+	docs, err := m.Near(context.Background(), "Places", -17.7, 179.999, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(docs), []string{"East", "West"}; !equalStrings(got, want) {
+		t.Errorf("Near across the antimeridian = %v, want %v", got, want)
+	}
+}
+
+func TestNearestScans(t *testing.T) {
+	m := NewMemory(places())
+	// A cover has at most nine cells, and every radius but the last full
+	// scan has a cover.
+	const maxScans = 9*(nearestMaxRounds-1) + 1
+	tests := []struct {
+		k    int
+		want []string
+	}{
+		{1, []string{"RSF"}},
+		{3, []string{"RSF", "Oakland", "San Francisco"}},
+		// More than there are: every located document.
+		{10, []string{"RSF", "Oakland", "San Francisco", "Los Angeles"}},
+	}
+	for _, test := range tests {
+		scans := 0
+		scanned := make(map[string]bool)
+		scan := func(ctx context.Context, collection, prefix string) ([]Document, error) {
+			scans++
+			if scanned[prefix] {
+				t.Errorf("nearest(k=%d) scanned %q twice", test.k, prefix)
+			}
+			scanned[prefix] = true
+			return m.scanGeohash(ctx, collection, prefix)
+		}
+		docs, err := nearest(context.Background(), "Places", originLat, originLon, test.k, scan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(docs); !equalStrings(got, test.want) {
+			t.Errorf("nearest(k=%d) = %v, want %v", test.k, got, test.want)
+		}
+		if scans > maxScans {
+			t.Errorf("nearest(k=%d) ran %d scans, want at most %d", test.k, scans, maxScans)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
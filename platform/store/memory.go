@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Memory is a Store holding its collections in memory. It is used for local
+// runs and tests, seeded from a JSON file or built directly. Documents with a
+// location get a GeohashField when they are added, and each collection keeps
+// them sorted by it so Near can scan prefixes like Firestore does.
+type Memory struct {
+	mu          sync.RWMutex
+	collections map[string][]Document
+	geohashes   map[string][]geohashEntry
+}
+
+// geohashEntry points from a geohash to the document at index in its
+// collection.
+type geohashEntry struct {
+	hash  string
+	index int
+}
+
+// NewMemory returns a store holding the given collections.
+func NewMemory(collections map[string][]Document) *Memory {
+	if collections == nil {
+		collections = make(map[string][]Document)
+	}
+	s := &Memory{collections: collections, geohashes: make(map[string][]geohashEntry)}
+	for collection := range collections {
+		s.index(collection)
+	}
+	return s
+}
+
+// index sets the geohash of each document in the collection that has a
+// location and rebuilds the collection's sorted geohash index.
+func (s *Memory) index(collection string) {
+	entries := make([]geohashEntry, 0)
+	for i, doc := range s.collections[collection] {
+		hash, ok := Geohash(doc)
+		if !ok {
+			continue
+		}
+		doc[GeohashField] = hash
+		entries = append(entries, geohashEntry{hash, i})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].hash < entries[j].hash })
+	s.geohashes[collection] = entries
+}
+
+// LoadFile reads a JSON object mapping collection names to arrays of
+// documents, e.g. {"Gyms": [{"name": "RSF", ...}]}.
+func LoadFile(path string) (*Memory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var collections map[string][]Document
+	if err := json.Unmarshal(data, &collections); err != nil {
+		return nil, err
+	}
+	return NewMemory(collections), nil
+}
+
+// Add appends doc to the collection.
+func (s *Memory) Add(collection string, doc Document) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collections[collection] = append(s.collections[collection], doc)
+	s.index(collection)
+}
+
+func (s *Memory) List(ctx context.Context, collection string) ([]Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	docs := make([]Document, 0, len(s.collections[collection]))
+	for _, doc := range s.collections[collection] {
+		docs = append(docs, copyDocument(doc))
+	}
+	return docs, nil
+}
+
+func (s *Memory) GetByName(ctx context.Context, collection string, name string) (Document, error) {
+	docs, err := s.Query(ctx, collection, "name", name)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrNotFound
+	}
+	return docs[0], nil
+}
+
+func (s *Memory) Query(ctx context.Context, collection string, field string, value interface{}) ([]Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	docs := make([]Document, 0)
+	for _, doc := range s.collections[collection] {
+		if equal(doc[field], value) {
+			docs = append(docs, copyDocument(doc))
+		}
+	}
+	return docs, nil
+}
+
+func (s *Memory) Near(ctx context.Context, collection string, latitude, longitude, radiusKm float64) ([]Document, error) {
+	return near(ctx, collection, latitude, longitude, radiusKm, s.scanGeohash)
+}
+
+func (s *Memory) Nearest(ctx context.Context, collection string, latitude, longitude float64, k int) ([]Document, error) {
+	return nearest(ctx, collection, latitude, longitude, k, s.scanGeohash)
+}
+
+// scanGeohash returns the documents whose geohash starts with prefix by
+// binary searching the collection's geohash index.
+func (s *Memory) scanGeohash(ctx context.Context, collection, prefix string) ([]Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries := s.geohashes[collection]
+	start := sort.Search(len(entries), func(i int) bool { return entries[i].hash >= prefix })
+	docs := make([]Document, 0)
+	for _, entry := range entries[start:] {
+		if !strings.HasPrefix(entry.hash, prefix) {
+			break
+		}
+		docs = append(docs, copyDocument(s.collections[collection][entry.index]))
+	}
+	return docs, nil
+}
+
+func (s *Memory) Close() error {
+	return nil
+}
+
+// copyDocument returns a shallow copy so callers can't mutate stored fields.
+func copyDocument(doc Document) Document {
+	out := make(Document, len(doc))
+	for k, v := range doc {
+		out[k] = v
+	}
+	return out
+}
+
+// equal compares field values the way Firestore does, treating every numeric
+// type as the same number.
+func equal(a, b interface{}) bool {
+	af, aNum := toFloat(a)
+	bf, bNum := toFloat(b)
+	if aNum && bNum {
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asuc-octo/octoapi/platform/geohash"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(map[string][]Document{Gyms: {
+		{"name": "RSF", "capacity": 100, "latitude": 37.8686, "longitude": -122.2627},
+		{"name": "Stadium", "capacity": int64(100)},
+		{"name": "Hearst", "capacity": 40.0},
+	}})
+
+	tests := []struct {
+		field string
+		value interface{}
+		want  []string
+	}{
+		{"name", "Hearst", []string{"Hearst"}},
+		// Numbers compare equal whatever their Go type, as in Firestore.
+		{"capacity", 100.0, []string{"RSF", "Stadium"}},
+		{"capacity", 40, []string{"Hearst"}},
+		{"capacity", "100", []string{}},
+	}
+	for _, test := range tests {
+		docs, err := m.Query(ctx, Gyms, test.field, test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(docs); !equalStrings(got, test.want) {
+			t.Errorf("Query(%s == %v) = %v, want %v", test.field, test.value, got, test.want)
+		}
+	}
+
+	doc, err := m.GetByName(ctx, Gyms, "RSF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := geohash.Encode(37.8686, -122.2627, geohash.Precision); doc[GeohashField] != want {
+		t.Errorf("RSF geohash = %v, want it set when added", doc[GeohashField])
+	}
+	if _, ok := m.collections[Gyms][1][GeohashField]; ok {
+		t.Error("a document without a location got a geohash")
+	}
+	if _, err := m.GetByName(ctx, Gyms, "Nope"); err != ErrNotFound {
+		t.Errorf("GetByName(Nope) error = %v, want ErrNotFound", err)
+	}
+
+	// Callers get copies they can't change the store through.
+	doc["name"] = "changed"
+	if docs, _ := m.List(ctx, Gyms); docs[0]["name"] != "RSF" {
+		t.Errorf("changing a returned document changed the store")
+	}
+
+	m.Add(Gyms, Document{"name": "Memorial", "latitude": 37.8690, "longitude": -122.2600})
+	docs, err := m.Near(ctx, Gyms, 37.8690, -122.2600, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(docs), []string{"Memorial", "RSF"}; !equalStrings(got, want) {
+		t.Errorf("Near after Add = %v, want %v", got, want)
+	}
+	if docs, _ := m.List(ctx, "Empty"); docs == nil || len(docs) != 0 {
+		t.Errorf("List(Empty) = %#v, want an empty list", docs)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	data := `{"Gyms": [{"name": "RSF", "latitude": 37.8686, "longitude": -122.2627}]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := m.Near(context.Background(), Gyms, 37.8690, -122.2600, 1)
+	if err != nil || len(docs) != 1 {
+		t.Errorf("Near on a loaded store = %v, %v; want RSF", docs, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"Gyms": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile accepted a collection that isn't an array")
+	}
+}
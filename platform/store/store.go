@@ -0,0 +1,41 @@
+// Package store abstracts the datastore behind the facility endpoints so they
+// can run against Firestore in production and an in-memory copy locally.
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// Collections holding the facility data.
+const (
+	Gyms        = "Gyms"
+	Libraries   = "Libraries"
+	DiningHalls = "Dining Halls"
+	Resources   = "Campus Resource"
+	// Holidays holds campus holidays, e.g. {"date": "2026-11-26", "name": "Thanksgiving"}.
+	Holidays = "Holidays"
+)
+
+// ErrNotFound is returned when no document matches a lookup.
+var ErrNotFound = errors.New("store: document not found")
+
+// Document is the raw field data of a single record.
+type Document map[string]interface{}
+
+// Store reads documents out of named collections.
+type Store interface {
+	// List returns every document in the collection.
+	List(ctx context.Context, collection string) ([]Document, error)
+	// GetByName returns the first document whose name field equals name.
+	GetByName(ctx context.Context, collection string, name string) (Document, error)
+	// Query returns the documents whose field equals value.
+	Query(ctx context.Context, collection string, field string, value interface{}) ([]Document, error)
+	// Near returns the documents within radiusKm kilometers of a point,
+	// nearest first, looking them up by their GeohashField.
+	Near(ctx context.Context, collection string, latitude, longitude, radiusKm float64) ([]Document, error)
+	// Nearest returns the k documents nearest a point, nearest first.
+	Nearest(ctx context.Context, collection string, latitude, longitude float64, k int) ([]Document, error)
+	// Close releases any connection held by the store.
+	Close() error
+}
@@ -0,0 +1,242 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Claims stamped on every token minted by the auth endpoints.
+const (
+	TokenIssuer   = "octoapi/auth"
+	TokenAudience = "octoapi"
+
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+
+	// AccessTokenTTL is how long an access token stays valid.
+	AccessTokenTTL = 72 * time.Hour
+	// RefreshTokenTTL is how long a refresh token stays valid. Each refresh
+	// hands out a new one.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// JwtAlgEnv names the environment variable that selects how tokens are
+// signed. Unset or HS256, HS384 or HS512 sign and verify with the shared
+// jwt_encryption_key secret. RS256 or ES256 opt in to signing with the active
+// key of the jwt_signing_keys secret and verifying against the
+// jwt_public_keys JWKS by kid. Tokens signed any other way are rejected.
+const JwtAlgEnv = "OCTOAPI_JWT_ALG"
+
+// JwtAcceptHS256Env names the environment variable that, set to true while
+// JwtAlgEnv is RS256 or ES256, keeps accepting HS256 tokens signed with the
+// shared secret, so moving to asymmetric keys doesn't log everyone out. Unset
+// it once the last of those tokens has expired.
+const JwtAcceptHS256Env = "OCTOAPI_JWT_ACCEPT_HS256"
+
+// Reasons a token is rejected on top of ErrInvalidToken. They all wrap
+// ErrInvalidToken so callers can match them with errors.Is.
+var (
+	ErrInvalidScheme   = invalidToken("authorization scheme must be Bearer")
+	ErrSigningMethod   = invalidToken("unexpected signing method")
+	ErrInvalidIssuer   = invalidToken("unexpected issuer")
+	ErrInvalidAudience = invalidToken("unexpected audience")
+	ErrInvalidIssuedAt = invalidToken("missing or future issued at time")
+	ErrNotYetValid     = invalidToken("not valid yet")
+	ErrMissingExpiry   = invalidToken("missing expiry")
+	ErrWrongTokenType  = invalidToken("wrong token type")
+)
+
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, reason)
+}
+
+// Claims is the payload of an OCTO API token. Scope lists the granted scopes
+// separated by spaces. Refresh tokens also carry a token id in jti and the
+// family they were rotated from in fam. Access tokens issued to an OAuth
+// client name it in azp.
+type Claims struct {
+	jwt.StandardClaims
+	UID      string `json:"uid"`
+	Type     string `json:"type"`
+	Scope    string `json:"scope,omitempty"`
+	Family   string `json:"fam,omitempty"`
+	ClientID string `json:"azp,omitempty"`
+}
+
+// IsTokenError reports whether err means the caller's token was rejected, as
+// opposed to the server failing to check it.
+func IsTokenError(err error) bool {
+	return errors.Is(err, ErrMissingToken) || errors.Is(err, ErrExpiredToken) || errors.Is(err, ErrInvalidToken)
+}
+
+// hmacMethod returns the HMAC algorithm selected by OCTOAPI_JWT_ALG, HS256
+// by default, or nil when tokens are signed with asymmetric keys.
+func hmacMethod() (jwt.SigningMethod, error) {
+	switch alg := os.Getenv(JwtAlgEnv); alg {
+	case "RS256", "ES256":
+		return nil, nil
+	case "":
+		return jwt.SigningMethodHS256, nil
+	case "HS256", "HS384", "HS512":
+		return jwt.GetSigningMethod(alg), nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported algorithm %q", JwtAlgEnv, alg)
+	}
+}
+
+// NewAccessToken signs an access token for uid granting scopes.
+func NewAccessToken(ctx context.Context, uid string, scopes []string) (string, error) {
+	return NewToken(ctx, Claims{UID: uid, Type: TokenTypeAccess, Scope: strings.Join(scopes, " ")}, AccessTokenTTL)
+}
+
+// NewClientAccessToken signs an access token for uid granting scopes to the
+// OAuth client clientID.
+func NewClientAccessToken(ctx context.Context, uid, clientID string, scopes []string) (string, error) {
+	claims := Claims{UID: uid, Type: TokenTypeAccess, Scope: strings.Join(scopes, " "), ClientID: clientID}
+	return NewToken(ctx, claims, AccessTokenTTL)
+}
+
+// NewToken stamps the issuer, audience and validity window on claims and
+// signs them. A zero ttl leaves the token without an expiry.
+func NewToken(ctx context.Context, claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.Issuer = TokenIssuer
+	claims.Audience = TokenAudience
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	if ttl != 0 {
+		claims.ExpiresAt = now.Add(ttl).Unix()
+	}
+	return signToken(ctx, claims)
+}
+
+// signToken signs claims with the HMAC secret or the active signing key.
+func signToken(ctx context.Context, claims jwt.Claims) (string, error) {
+	method, err := hmacMethod()
+	if err != nil {
+		return "", err
+	}
+	var key interface{}
+	kid := ""
+	if method != nil {
+		key, err = JwtSecret(ctx)
+	} else {
+		var signing *signingKey
+		signing, err = loadSigningKey(ctx)
+		if err == nil {
+			method, key, kid = signing.method, signing.key, signing.kid
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if alg := os.Getenv(JwtAlgEnv); alg != "" && alg != method.Alg() {
+		return "", fmt.Errorf("%s is %s but the active signing key is %s", JwtAlgEnv, alg, method.Alg())
+	}
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(key)
+}
+
+// ParseToken verifies the signature and claims of a token of the given type.
+// Rejected tokens produce an error for which IsTokenError is true.
+func ParseToken(ctx context.Context, tokenString, tokenType string) (*Claims, error) {
+	keyFunc, err := verificationKeyFunc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, parseError(err)
+	}
+	switch {
+	case claims.Issuer != TokenIssuer:
+		return nil, ErrInvalidIssuer
+	case claims.Audience != TokenAudience:
+		return nil, ErrInvalidAudience
+	case claims.IssuedAt == 0:
+		return nil, ErrInvalidIssuedAt
+	case claims.Type != tokenType:
+		return nil, ErrWrongTokenType
+	case claims.UID == "":
+		return nil, ErrInvalidToken
+	case claims.ExpiresAt == 0:
+		return nil, ErrMissingExpiry
+	case tokenType == TokenTypeRefresh && (claims.Id == "" || claims.Family == ""):
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// parseError maps a jwt-go validation error onto the typed token errors.
+func parseError(err error) error {
+	validationErr, ok := err.(*jwt.ValidationError)
+	if !ok {
+		return ErrInvalidToken
+	}
+	switch {
+	case errors.Is(validationErr.Inner, ErrInvalidToken):
+		return validationErr.Inner
+	case validationErr.Errors&jwt.ValidationErrorExpired != 0:
+		return ErrExpiredToken
+	case validationErr.Errors&jwt.ValidationErrorNotValidYet != 0:
+		return ErrNotYetValid
+	case validationErr.Errors&jwt.ValidationErrorIssuedAt != 0:
+		return ErrInvalidIssuedAt
+	}
+	return ErrInvalidToken
+}
+
+// verificationKeyFunc returns the jwt.Keyfunc that picks the key a token is
+// checked against, rejecting tokens whose algorithm does not match it.
+func verificationKeyFunc(ctx context.Context) (jwt.Keyfunc, error) {
+	method, err := hmacMethod()
+	if err != nil {
+		return nil, err
+	}
+	if method != nil {
+		secret, err := JwtSecret(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func(token *jwt.Token) (interface{}, error) {
+			if token.Method.Alg() != method.Alg() {
+				return nil, ErrSigningMethod
+			}
+			return secret, nil
+		}, nil
+	}
+	set, err := loadPublicKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var legacySecret []byte
+	if os.Getenv(JwtAcceptHS256Env) == "true" {
+		if legacySecret, err = JwtSecret(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return func(token *jwt.Token) (interface{}, error) {
+		if legacySecret != nil && token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+			return legacySecret, nil
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := set.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, ErrSigningMethod
+		}
+		return key.key, nil
+	}, nil
+}
@@ -0,0 +1,278 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+const testHMACSecret = "test-secret"
+
+// useHMACSecret signs and verifies tokens with alg and a fixed secret.
+func useHMACSecret(t *testing.T, alg string) {
+	t.Helper()
+	t.Setenv(JwtAlgEnv, alg)
+	t.Setenv(secretEnvName(JwtKeySecret), testHMACSecret)
+}
+
+// validClaims returns the claims of an access token for u1 issued now.
+func validClaims() Claims {
+	now := time.Now()
+	return Claims{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    TokenIssuer,
+			Audience:  TokenAudience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		},
+		UID:   "u1",
+		Type:  TokenTypeAccess,
+		Scope: ScopeGymsRead,
+	}
+}
+
+func signHMAC(t *testing.T, method jwt.SigningMethod, claims Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(testHMACSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestParseToken(t *testing.T) {
+	useHMACSecret(t, "HS256")
+	now := time.Now()
+	tests := []struct {
+		name      string
+		tokenType string
+		edit      func(*Claims)
+		want      error
+	}{
+		{"valid access token", TokenTypeAccess, func(*Claims) {}, nil},
+		{"valid refresh token", TokenTypeRefresh, func(c *Claims) {
+			c.Type, c.Id, c.Family = TokenTypeRefresh, "jti", "fam"
+		}, nil},
+		{"expired", TokenTypeAccess, func(c *Claims) { c.ExpiresAt = now.Add(-time.Minute).Unix() }, ErrExpiredToken},
+		{"no expiry", TokenTypeAccess, func(c *Claims) { c.ExpiresAt = 0 }, ErrMissingExpiry},
+		{"no issued at", TokenTypeAccess, func(c *Claims) { c.IssuedAt = 0 }, ErrInvalidIssuedAt},
+		{"issued in the future", TokenTypeAccess, func(c *Claims) { c.IssuedAt = now.Add(time.Hour).Unix() }, ErrInvalidIssuedAt},
+		{"not valid yet", TokenTypeAccess, func(c *Claims) { c.NotBefore = now.Add(time.Hour).Unix() }, ErrNotYetValid},
+		{"wrong audience", TokenTypeAccess, func(c *Claims) { c.Audience = "someone-else" }, ErrInvalidAudience},
+		{"wrong issuer", TokenTypeAccess, func(c *Claims) { c.Issuer = "someone-else" }, ErrInvalidIssuer},
+		{"no uid", TokenTypeAccess, func(c *Claims) { c.UID = "" }, ErrInvalidToken},
+		{"refresh token as access token", TokenTypeAccess, func(c *Claims) {
+			c.Type, c.Id, c.Family = TokenTypeRefresh, "jti", "fam"
+		}, ErrWrongTokenType},
+		{"access token as refresh token", TokenTypeRefresh, func(*Claims) {}, ErrWrongTokenType},
+		{"refresh token without jti", TokenTypeRefresh, func(c *Claims) {
+			c.Type, c.Family = TokenTypeRefresh, "fam"
+		}, ErrInvalidToken},
+		{"refresh token without family", TokenTypeRefresh, func(c *Claims) {
+			c.Type, c.Id = TokenTypeRefresh, "jti"
+		}, ErrInvalidToken},
+	}
+	for _, test := range tests {
+		claims := validClaims()
+		test.edit(&claims)
+		got, err := ParseToken(context.Background(), signHMAC(t, jwt.SigningMethodHS256, claims), test.tokenType)
+		if err != test.want {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.want)
+			continue
+		}
+		if err == nil && got.UID != "u1" {
+			t.Errorf("%s: uid = %q, want u1", test.name, got.UID)
+		}
+	}
+}
+
+func TestParseTokenDefaultAlg(t *testing.T) {
+	useHMACSecret(t, "")
+	ctx := context.Background()
+	signed, err := NewAccessToken(ctx, "u1", []string{ScopeGymsRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := new(jwt.Parser).ParseUnverified(signed, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alg := token.Method.Alg(); alg != "HS256" {
+		t.Errorf("default signing method = %s, want HS256", alg)
+	}
+	if _, err := ParseToken(ctx, signed, TokenTypeAccess); err != nil {
+		t.Errorf("ParseToken = %v", err)
+	}
+}
+
+func TestParseTokenSignature(t *testing.T) {
+	useHMACSecret(t, "HS256")
+	valid := signHMAC(t, jwt.SigningMethodHS256, validClaims())
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSecret, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("other-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"HS384 when HS256 is configured", signHMAC(t, jwt.SigningMethodHS384, validClaims()), ErrSigningMethod},
+		{"unsigned", none, ErrSigningMethod},
+		{"signed with another secret", otherSecret, ErrInvalidToken},
+		{"tampered payload", tamper(t, valid), ErrInvalidToken},
+		{"garbage", "not.a.token", ErrInvalidToken},
+	}
+	for _, test := range tests {
+		if _, err := ParseToken(context.Background(), test.token, TokenTypeAccess); err != test.want {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+// tamper returns token with its uid changed and the original signature.
+func tamper(t *testing.T, token string) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	claims := validClaims()
+	claims.UID = "admin"
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts[1] = jwt.EncodeSegment(payload)
+	return strings.Join(parts, ".")
+}
+
+func TestParseTokenKeySet(t *testing.T) {
+	clearKeyCache(t)
+	useES256Keys(t, "k1")
+	ctx := context.Background()
+
+	signed, err := NewAccessToken(ctx, "u1", []string{ScopeGymsRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := ParseToken(ctx, signed, TokenTypeAccess); err != nil || claims.UID != "u1" {
+		t.Fatalf("ParseToken(ES256) = %v, %v", claims, err)
+	}
+
+	// The classic confusion: an HMAC token keyed with the published public
+	// key, which is no secret.
+	public, err := PublicKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicJSON, _ := json.Marshal(public.Keys[0])
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	confused.Header["kid"] = "k1"
+	confusedToken, err := confused.SignedString(publicJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := jwt.NewWithClaims(jwt.SigningMethodES256, validClaims())
+	unknown.Header["kid"] = "k0"
+	signing, err := loadSigningKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownToken, err := unknown.SignedString(signing.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"HS256 keyed with the public key", confusedToken, ErrSigningMethod},
+		{"HS256 with the shared secret", signHMAC(t, jwt.SigningMethodHS256, validClaims()), ErrUnknownKey},
+		{"unknown kid", unknownToken, ErrUnknownKey},
+	}
+	for _, test := range tests {
+		if _, err := ParseToken(ctx, test.token, TokenTypeAccess); err != test.want {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	// While moving off the shared secret, HS256 tokens signed with it are
+	// accepted too, but still not one keyed with the public key.
+	t.Setenv(JwtAcceptHS256Env, "true")
+	t.Setenv(secretEnvName(JwtKeySecret), testHMACSecret)
+	migration := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"ES256", signed, nil},
+		{"HS256 with the shared secret", signHMAC(t, jwt.SigningMethodHS256, validClaims()), nil},
+		{"HS256 keyed with the public key", confusedToken, ErrInvalidToken},
+		{"HS384 with the shared secret", signHMAC(t, jwt.SigningMethodHS384, validClaims()), ErrUnknownKey},
+	}
+	for _, test := range migration {
+		if _, err := ParseToken(ctx, test.token, TokenTypeAccess); err != test.want {
+			t.Errorf("accepting HS256, %s: error = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	useHMACSecret(t, "HS256")
+	ctx := context.Background()
+	db := users.NewMemory([]users.User{{UID: "u1"}})
+	var first string
+	if err := db.Update(ctx, "u1", func(user *users.User) error {
+		var err error
+		first, err = StartRefreshFamily(ctx, user, []string{ScopeGymsRead})
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	parse := func(token string) *Claims {
+		t.Helper()
+		claims, err := ParseToken(ctx, token, TokenTypeRefresh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return claims
+	}
+	firstClaims := parse(first)
+
+	second, scopes, err := RotateRefreshToken(ctx, db, firstClaims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondClaims := parse(second)
+	if secondClaims.Family != firstClaims.Family || secondClaims.Id == firstClaims.Id {
+		t.Errorf("rotated token fam/jti = %s/%s, want %s/not %s",
+			secondClaims.Family, secondClaims.Id, firstClaims.Family, firstClaims.Id)
+	}
+	if len(scopes) != 1 || scopes[0] != ScopeGymsRead {
+		t.Errorf("rotated scopes = %v, want [%s]", scopes, ScopeGymsRead)
+	}
+
+	otherFamily := *secondClaims
+	otherFamily.Family = "other"
+	if _, _, err := RotateRefreshToken(ctx, db, &otherFamily); err != ErrRevokedToken {
+		t.Errorf("token of another family: error = %v, want ErrRevokedToken", err)
+	}
+
+	// Replaying the first token revokes the family, current token included.
+	if _, _, err := RotateRefreshToken(ctx, db, firstClaims); err != ErrReusedToken {
+		t.Errorf("reused token: error = %v, want ErrReusedToken", err)
+	}
+	if _, _, err := RotateRefreshToken(ctx, db, secondClaims); err != ErrRevokedToken {
+		t.Errorf("token after reuse: error = %v, want ErrRevokedToken", err)
+	}
+}
@@ -0,0 +1,131 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// Collection holds one document per usage record.
+const Collection = "usage"
+
+// memoryCapacity is how many records Memory keeps before dropping the oldest.
+const memoryCapacity = 100000
+
+// Memory is a Sink keeping the latest records in process memory, in a ring
+// buffer that overwrites the oldest once full.
+type Memory struct {
+	mu      sync.Mutex
+	records []Record
+	next    int // index the next record goes to once the buffer is full
+}
+
+// NewMemory returns an empty in-memory sink.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Record(ctx context.Context, record Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.records) < memoryCapacity {
+		m.records = append(m.records, record)
+		return nil
+	}
+	m.records[m.next] = record
+	m.next = (m.next + 1) % memoryCapacity
+	return nil
+}
+
+func (m *Memory) Records(ctx context.Context, q Query) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Record, 0)
+	// Oldest first: from next to the end, then from the start.
+	for _, part := range [][]Record{m.records[m.next:], m.records[:m.next]} {
+		for _, r := range part {
+			if q.matches(r) {
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Firestore is a Sink writing each record to the usage collection. Querying
+// by user needs a composite index on uid and time.
+type Firestore struct {
+	client *firestore.Client
+}
+
+// NewFirestore wraps client. Closing the sink closes the client.
+func NewFirestore(client *firestore.Client) *Firestore {
+	return &Firestore{client: client}
+}
+
+func (f *Firestore) Record(ctx context.Context, record Record) error {
+	_, _, err := f.client.Collection(Collection).Add(ctx, record)
+	return err
+}
+
+func (f *Firestore) Records(ctx context.Context, q Query) ([]Record, error) {
+	query := f.client.Collection(Collection).Where("time", ">=", q.From).Where("time", "<", q.To)
+	if q.UID != "" {
+		query = query.Where("uid", "==", q.UID)
+	}
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+	out := make([]Record, 0)
+	for {
+		snap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var r Record
+		if err := snap.DataTo(&r); err != nil {
+			return nil, err
+		}
+		// Filtering by key here saves another composite index.
+		if q.KeyID != "" && r.KeyID != q.KeyID {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (f *Firestore) Close() error {
+	return f.client.Close()
+}
+
+// Log is a Sink writing each record to the standard logger as JSON, for a
+// log-based pipeline to pick up. It can't be queried.
+type Log struct{}
+
+func (Log) Record(ctx context.Context, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	log.Printf("usage %s", line)
+	return nil
+}
+
+func (Log) Records(ctx context.Context, q Query) ([]Record, error) {
+	return nil, ErrNotQueryable
+}
+
+func (Log) Close() error {
+	return nil
+}
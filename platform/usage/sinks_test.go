@@ -0,0 +1,37 @@
+package usage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryDropsOldest(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	const extra = 3
+	for i := int64(0); i < memoryCapacity+extra; i++ {
+		if err := m.Record(ctx, Record{UID: "u1", Time: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := m.Records(ctx, Query{From: 0, To: memoryCapacity + extra})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != memoryCapacity {
+		t.Fatalf("kept %d records, want %d", len(got), memoryCapacity)
+	}
+	for i, r := range got {
+		if want := int64(i + extra); r.Time != want {
+			t.Fatalf("record %d has time %d, want %d", i, r.Time, want)
+		}
+	}
+
+	got, err = m.Records(ctx, Query{From: memoryCapacity, To: memoryCapacity + extra})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != extra {
+		t.Errorf("newest records = %d, want %d", len(got), extra)
+	}
+}
@@ -0,0 +1,104 @@
+// Package usage records which endpoints API users call, through a pluggable
+// sink, and summarizes the records for the usage endpoints.
+package usage
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// ErrNotQueryable is returned by sinks that can only write records.
+var ErrNotQueryable = errors.New("usage: sink can't be queried")
+
+// Record describes one authenticated request.
+type Record struct {
+	UID       string `firestore:"uid" json:"uid"`
+	KeyID     string `firestore:"key_id" json:"key_id,omitempty"`
+	Endpoint  string `firestore:"endpoint" json:"endpoint"`
+	Method    string `firestore:"method" json:"method"`
+	Status    int    `firestore:"status" json:"status"`
+	LatencyMs int64  `firestore:"latency_ms" json:"latency_ms"`
+	Time      int64  `firestore:"time" json:"time"`
+}
+
+// Query selects the records made in [From, To), only those of UID and of
+// the API key KeyID unless they are empty.
+type Query struct {
+	UID   string
+	KeyID string
+	From  int64
+	To    int64
+}
+
+func (q Query) matches(r Record) bool {
+	return (q.UID == "" || r.UID == q.UID) && (q.KeyID == "" || r.KeyID == q.KeyID) &&
+		r.Time >= q.From && r.Time < q.To
+}
+
+// Sink receives usage records.
+type Sink interface {
+	// Record stores one record.
+	Record(ctx context.Context, record Record) error
+	// Records returns the records matching q, or ErrNotQueryable.
+	Records(ctx context.Context, q Query) ([]Record, error)
+	// Close releases any connection held by the sink.
+	Close() error
+}
+
+// Ways to group records in a Summary.
+const (
+	ByEndpoint = "endpoint"
+	ByUser     = "uid"
+)
+
+// Count summarizes the records sharing a group.
+type Count struct {
+	UID          string  `json:"uid,omitempty"`
+	Endpoint     string  `json:"endpoint,omitempty"`
+	Requests     int     `json:"requests"`
+	Errors       int     `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// Summarize counts records grouped by endpoint, by user or both, busiest
+// group first. Responses with a 4xx or 5xx status count as errors.
+func Summarize(records []Record, byEndpoint, byUser bool) []Count {
+	type group struct{ uid, endpoint string }
+	counts := make(map[group]*Count)
+	latency := make(map[group]int64)
+	for _, r := range records {
+		var g group
+		if byUser {
+			g.uid = r.UID
+		}
+		if byEndpoint {
+			g.endpoint = r.Endpoint
+		}
+		c, ok := counts[g]
+		if !ok {
+			c = &Count{UID: g.uid, Endpoint: g.endpoint}
+			counts[g] = c
+		}
+		c.Requests++
+		if r.Status >= 400 {
+			c.Errors++
+		}
+		latency[g] += r.LatencyMs
+	}
+	out := make([]Count, 0, len(counts))
+	for g, c := range counts {
+		c.AvgLatencyMs = float64(latency[g]) / float64(c.Requests)
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Requests != out[j].Requests {
+			return out[i].Requests > out[j].Requests
+		}
+		if out[i].UID != out[j].UID {
+			return out[i].UID < out[j].UID
+		}
+		return out[i].Endpoint < out[j].Endpoint
+	})
+	return out
+}
@@ -0,0 +1,323 @@
+package users
+
+import (
+	"context"
+	"sort"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Firestore is a Store backed by a Firestore client.
+type Firestore struct {
+	client *firestore.Client
+}
+
+// NewFirestore wraps client. Closing the store closes the client.
+func NewFirestore(client *firestore.Client) *Firestore {
+	return &Firestore{client: client}
+}
+
+func (s *Firestore) Get(ctx context.Context, uid string) (*User, error) {
+	snap, err := s.client.Collection(Collection).Doc(uid).Get(ctx)
+	return decode(snap, err)
+}
+
+func (s *Firestore) Create(ctx context.Context, user *User) error {
+	_, err := s.client.Collection(Collection).Doc(user.UID).Create(ctx, user)
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrExists
+	}
+	return err
+}
+
+func (s *Firestore) Update(ctx context.Context, uid string, fn func(*User) error) error {
+	doc := s.client.Collection(Collection).Doc(uid)
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		user, err := decode(tx.Get(doc))
+		if err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+		return tx.Set(doc, user)
+	})
+}
+
+func (s *Firestore) Close() error {
+	return s.client.Close()
+}
+
+func decode(snap *firestore.DocumentSnapshot, err error) (*User, error) {
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var user User
+	if err := snap.DataTo(&user); err != nil {
+		return nil, err
+	}
+	if user.UID == "" {
+		user.UID = snap.Ref.ID
+	}
+	return &user, nil
+}
+
+func (s *Firestore) GetAPIKey(ctx context.Context, id string) (*APIKey, error) {
+	snap, err := s.client.Collection(APIKeysCollection).Doc(id).Get(ctx)
+	return decodeAPIKey(snap, err)
+}
+
+func (s *Firestore) ListAPIKeys(ctx context.Context, uid string) ([]APIKey, error) {
+	iter := s.client.Collection(APIKeysCollection).Where("uid", "==", uid).Documents(ctx)
+	defer iter.Stop()
+	keys := make([]APIKey, 0)
+	for {
+		snap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		key, err := decodeAPIKey(snap, err)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt < keys[j].CreatedAt })
+	return keys, nil
+}
+
+func (s *Firestore) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	_, err := s.client.Collection(APIKeysCollection).Doc(key.ID).Create(ctx, key)
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrExists
+	}
+	return err
+}
+
+func (s *Firestore) UpdateAPIKey(ctx context.Context, id string, fn func(*APIKey) error) error {
+	doc := s.client.Collection(APIKeysCollection).Doc(id)
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		key, err := decodeAPIKey(tx.Get(doc))
+		if err != nil {
+			return err
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+		return tx.Set(doc, key)
+	})
+}
+
+func (s *Firestore) ReplaceAPIKey(ctx context.Context, id string, fn func(*APIKey) (*APIKey, error)) error {
+	keys := s.client.Collection(APIKeysCollection)
+	doc := keys.Doc(id)
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		key, err := decodeAPIKey(tx.Get(doc))
+		if err != nil {
+			return err
+		}
+		added, err := fn(key)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(doc, key); err != nil {
+			return err
+		}
+		return tx.Create(keys.Doc(added.ID), added)
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrExists
+	}
+	return err
+}
+
+func (s *Firestore) TouchAPIKey(ctx context.Context, id string, t int64) error {
+	_, err := s.client.Collection(APIKeysCollection).Doc(id).Update(ctx, []firestore.Update{
+		{Path: "last_used_at", Value: t},
+	})
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func decodeAPIKey(snap *firestore.DocumentSnapshot, err error) (*APIKey, error) {
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var key APIKey
+	if err := snap.DataTo(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *Firestore) List(ctx context.Context) ([]User, error) {
+	iter := s.client.Collection(Collection).Documents(ctx)
+	defer iter.Stop()
+	list := make([]User, 0)
+	for {
+		snap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		user, err := decode(snap, err)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *user)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt < list[j].CreatedAt })
+	return list, nil
+}
+
+func (s *Firestore) UpdateAudited(ctx context.Context, uid string, fn func(*User) error, entry *AuditEntry) error {
+	doc := s.client.Collection(Collection).Doc(uid)
+	audit := s.client.Collection(AuditLogCollection).NewDoc()
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		user, err := decode(tx.Get(doc))
+		if err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+		if err := tx.Set(doc, user); err != nil {
+			return err
+		}
+		return tx.Create(audit, entry)
+	})
+	if err != nil {
+		return err
+	}
+	entry.ID = audit.ID
+	return nil
+}
+
+func (s *Firestore) ListAuditLog(ctx context.Context, target string, limit int) ([]AuditEntry, error) {
+	// Filtering by target needs a composite index on target and at.
+	query := s.client.Collection(AuditLogCollection).OrderBy("at", firestore.Desc)
+	if target != "" {
+		query = query.Where("target", "==", target)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+	entries := make([]AuditEntry, 0)
+	for {
+		snap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var entry AuditEntry
+		if err := snap.DataTo(&entry); err != nil {
+			return nil, err
+		}
+		entry.ID = snap.Ref.ID
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (s *Firestore) GetClient(ctx context.Context, id string) (*Client, error) {
+	snap, err := s.client.Collection(ClientsCollection).Doc(id).Get(ctx)
+	return decodeClient(snap, err)
+}
+
+func (s *Firestore) ListClients(ctx context.Context, uid string) ([]Client, error) {
+	iter := s.client.Collection(ClientsCollection).Where("owner_uid", "==", uid).Documents(ctx)
+	defer iter.Stop()
+	clients := make([]Client, 0)
+	for {
+		snap, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		client, err := decodeClient(snap, err)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, *client)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].CreatedAt < clients[j].CreatedAt })
+	return clients, nil
+}
+
+func (s *Firestore) CreateClient(ctx context.Context, client *Client) error {
+	_, err := s.client.Collection(ClientsCollection).Doc(client.ID).Create(ctx, client)
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrExists
+	}
+	return err
+}
+
+func (s *Firestore) UpdateClient(ctx context.Context, id string, fn func(*Client) error) error {
+	doc := s.client.Collection(ClientsCollection).Doc(id)
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		client, err := decodeClient(tx.Get(doc))
+		if err != nil {
+			return err
+		}
+		if err := fn(client); err != nil {
+			return err
+		}
+		return tx.Set(doc, client)
+	})
+}
+
+func decodeClient(snap *firestore.DocumentSnapshot, err error) (*Client, error) {
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var client Client
+	if err := snap.DataTo(&client); err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (s *Firestore) CreateAuthCode(ctx context.Context, code *AuthCode) error {
+	_, err := s.client.Collection(AuthCodesCollection).Doc(code.ID).Create(ctx, code)
+	if status.Code(err) == codes.AlreadyExists {
+		return ErrExists
+	}
+	return err
+}
+
+func (s *Firestore) UpdateAuthCode(ctx context.Context, id string, fn func(*AuthCode) error) error {
+	doc := s.client.Collection(AuthCodesCollection).Doc(id)
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(doc)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var code AuthCode
+		if err := snap.DataTo(&code); err != nil {
+			return err
+		}
+		if err := fn(&code); err != nil {
+			return err
+		}
+		return tx.Set(doc, &code)
+	})
+}
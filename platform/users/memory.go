@@ -0,0 +1,297 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Memory is a Store holding users in memory. It is used for local runs and
+// tests; changes are lost when the process exits.
+type Memory struct {
+	mu       sync.Mutex
+	users    map[string]User
+	apiKeys  map[string]APIKey
+	clients  map[string]Client
+	codes    map[string]AuthCode
+	auditLog []AuditEntry
+}
+
+// NewMemory returns a store holding the given users.
+func NewMemory(users []User) *Memory {
+	s := &Memory{
+		users:   make(map[string]User, len(users)),
+		apiKeys: make(map[string]APIKey),
+		clients: make(map[string]Client),
+		codes:   make(map[string]AuthCode),
+	}
+	for _, user := range users {
+		s.users[user.UID] = user
+	}
+	return s
+}
+
+// LoadFile reads a JSON array of users, e.g. [{"uid": "abc", "blocked": false}].
+func LoadFile(path string) (*Memory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var users []User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, err
+	}
+	return NewMemory(users), nil
+}
+
+func (s *Memory) Get(ctx context.Context, uid string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[uid]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (s *Memory) List(ctx context.Context) ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]User, 0, len(s.users))
+	for _, user := range s.users {
+		list = append(list, user)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt < list[j].CreatedAt })
+	return list, nil
+}
+
+func (s *Memory) Create(ctx context.Context, user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[user.UID]; ok {
+		return ErrExists
+	}
+	s.users[user.UID] = *user
+	return nil
+}
+
+func (s *Memory) Update(ctx context.Context, uid string, fn func(*User) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[uid]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := fn(&user); err != nil {
+		return err
+	}
+	s.users[uid] = user
+	return nil
+}
+
+func (s *Memory) GetAPIKey(ctx context.Context, id string) (*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	key = copyAPIKey(key)
+	return &key, nil
+}
+
+func (s *Memory) ListAPIKeys(ctx context.Context, uid string) ([]APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]APIKey, 0)
+	for _, key := range s.apiKeys {
+		if key.UID == uid {
+			keys = append(keys, copyAPIKey(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt < keys[j].CreatedAt })
+	return keys, nil
+}
+
+func (s *Memory) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.apiKeys[key.ID]; ok {
+		return ErrExists
+	}
+	s.apiKeys[key.ID] = copyAPIKey(*key)
+	return nil
+}
+
+func (s *Memory) UpdateAPIKey(ctx context.Context, id string, fn func(*APIKey) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key = copyAPIKey(key)
+	if err := fn(&key); err != nil {
+		return err
+	}
+	s.apiKeys[id] = key
+	return nil
+}
+
+func (s *Memory) ReplaceAPIKey(ctx context.Context, id string, fn func(*APIKey) (*APIKey, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key = copyAPIKey(key)
+	added, err := fn(&key)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.apiKeys[added.ID]; ok {
+		return ErrExists
+	}
+	s.apiKeys[id] = key
+	s.apiKeys[added.ID] = copyAPIKey(*added)
+	return nil
+}
+
+func (s *Memory) TouchAPIKey(ctx context.Context, id string, t int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key.LastUsedAt = t
+	s.apiKeys[id] = key
+	return nil
+}
+
+func (s *Memory) GetClient(ctx context.Context, id string) (*Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, ok := s.clients[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	client = copyClient(client)
+	return &client, nil
+}
+
+func (s *Memory) ListClients(ctx context.Context, uid string) ([]Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clients := make([]Client, 0)
+	for _, client := range s.clients {
+		if client.OwnerUID == uid {
+			clients = append(clients, copyClient(client))
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].CreatedAt < clients[j].CreatedAt })
+	return clients, nil
+}
+
+func (s *Memory) CreateClient(ctx context.Context, client *Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[client.ID]; ok {
+		return ErrExists
+	}
+	s.clients[client.ID] = copyClient(*client)
+	return nil
+}
+
+func (s *Memory) UpdateClient(ctx context.Context, id string, fn func(*Client) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, ok := s.clients[id]
+	if !ok {
+		return ErrNotFound
+	}
+	client = copyClient(client)
+	if err := fn(&client); err != nil {
+		return err
+	}
+	s.clients[id] = client
+	return nil
+}
+
+func (s *Memory) CreateAuthCode(ctx context.Context, code *AuthCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.codes[code.ID]; ok {
+		return ErrExists
+	}
+	s.codes[code.ID] = copyAuthCode(*code)
+	return nil
+}
+
+func (s *Memory) UpdateAuthCode(ctx context.Context, id string, fn func(*AuthCode) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[id]
+	if !ok {
+		return ErrNotFound
+	}
+	code = copyAuthCode(code)
+	if err := fn(&code); err != nil {
+		return err
+	}
+	s.codes[id] = code
+	return nil
+}
+
+func (s *Memory) UpdateAudited(ctx context.Context, uid string, fn func(*User) error, entry *AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[uid]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := fn(&user); err != nil {
+		return err
+	}
+	s.users[uid] = user
+	entry.ID = strconv.Itoa(len(s.auditLog) + 1)
+	s.auditLog = append(s.auditLog, *entry)
+	return nil
+}
+
+func (s *Memory) ListAuditLog(ctx context.Context, target string, limit int) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]AuditEntry, 0)
+	for i := len(s.auditLog) - 1; i >= 0; i-- {
+		if entry := s.auditLog[i]; target == "" || entry.Target == target {
+			entries = append(entries, entry)
+		}
+	}
+	return newestFirst(entries, limit), nil
+}
+
+// copyAPIKey copies the key's scopes so callers can't mutate stored ones.
+func copyAPIKey(key APIKey) APIKey {
+	key.Scopes = append([]string(nil), key.Scopes...)
+	return key
+}
+
+func copyClient(client Client) Client {
+	client.RedirectURIs = append([]string(nil), client.RedirectURIs...)
+	client.Scopes = append([]string(nil), client.Scopes...)
+	return client
+}
+
+func copyAuthCode(code AuthCode) AuthCode {
+	code.Scopes = append([]string(nil), code.Scopes...)
+	return code
+}
+
+func (s *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryReplaceAPIKey(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(nil)
+	if err := s.CreateAPIKey(ctx, &APIKey{ID: "old", UID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	errDenied := errors.New("denied")
+	if err := s.ReplaceAPIKey(ctx, "old", func(old *APIKey) (*APIKey, error) {
+		old.RevokedAt = 1
+		return nil, errDenied
+	}); err != errDenied {
+		t.Fatalf("ReplaceAPIKey = %v, want fn's error", err)
+	}
+	if old, _ := s.GetAPIKey(ctx, "old"); old.Revoked() {
+		t.Error("old key revoked although fn failed")
+	}
+
+	if err := s.ReplaceAPIKey(ctx, "old", func(old *APIKey) (*APIKey, error) {
+		old.RevokedAt = 1
+		return &APIKey{ID: "new", UID: old.UID}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if old, _ := s.GetAPIKey(ctx, "old"); !old.Revoked() {
+		t.Error("old key not revoked")
+	}
+	if _, err := s.GetAPIKey(ctx, "new"); err != nil {
+		t.Errorf("new key: %v", err)
+	}
+
+	if err := s.ReplaceAPIKey(ctx, "missing", func(*APIKey) (*APIKey, error) {
+		t.Error("fn ran for a missing key")
+		return nil, nil
+	}); err != ErrNotFound {
+		t.Errorf("ReplaceAPIKey(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryUpdateAudited(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory([]User{{UID: "u1"}})
+
+	errDenied := errors.New("denied")
+	err := s.UpdateAudited(ctx, "u1", func(user *User) error {
+		user.Blocked = true
+		return errDenied
+	}, &AuditEntry{Action: ActionBlock, Target: "u1"})
+	if err != errDenied {
+		t.Fatalf("UpdateAudited = %v, want fn's error", err)
+	}
+	if user, _ := s.Get(ctx, "u1"); user.Blocked {
+		t.Error("user blocked although fn failed")
+	}
+	if log, _ := s.ListAuditLog(ctx, "", 0); len(log) != 0 {
+		t.Errorf("audit log = %v, want nothing written", log)
+	}
+
+	entry := &AuditEntry{Action: ActionBlock, Target: "u1"}
+	if err := s.UpdateAudited(ctx, "u1", func(user *User) error {
+		user.Blocked = true
+		return nil
+	}, entry); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := s.Get(ctx, "u1"); !user.Blocked {
+		t.Error("user not blocked")
+	}
+	if log, _ := s.ListAuditLog(ctx, "u1", 0); len(log) != 1 || log[0].ID != entry.ID || entry.ID == "" {
+		t.Errorf("audit log = %v, want the entry with id %q", log, entry.ID)
+	}
+
+	if err := s.UpdateAudited(ctx, "missing", func(*User) error { return nil }, &AuditEntry{}); err != ErrNotFound {
+		t.Errorf("UpdateAudited(missing) = %v, want ErrNotFound", err)
+	}
+}
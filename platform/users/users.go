@@ -0,0 +1,238 @@
+// Package users keeps the API accounts stored in the users collection: who
+// has signed up, whether they are blocked and which refresh token is current.
+package users
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// Collection holds one document per user, keyed by Firebase uid.
+const Collection = "users"
+
+// APIKeysCollection holds one document per API key, keyed by key id, next to
+// the users collection.
+const APIKeysCollection = "api_keys"
+
+// AuditLogCollection records every change an admin makes to a user.
+const AuditLogCollection = "audit_log"
+
+// ClientsCollection holds one document per OAuth client, keyed by client id.
+const ClientsCollection = "oauth_clients"
+
+// AuthCodesCollection holds one document per OAuth authorization code, keyed
+// by a hash of the code. Give it a TTL policy on expires_at.
+const AuthCodesCollection = "oauth_codes"
+
+// Actions recorded in the audit log.
+const (
+	ActionBlock   = "user.block"
+	ActionUnblock = "user.unblock"
+)
+
+// Errors returned by a Store.
+var (
+	ErrNotFound = errors.New("users: not found")
+	ErrExists   = errors.New("users: already exists")
+)
+
+// User is a document in the users collection.
+type User struct {
+	UID       string `firestore:"uid" json:"uid"`
+	CreatedAt int64  `firestore:"created_at" json:"created_at"`
+	Blocked   bool   `firestore:"blocked" json:"blocked"`
+	BlockedAt int64  `firestore:"blocked_at" json:"blocked_at"`
+	// BlockedReason is the reason the admin gave for blocking the user.
+	BlockedReason string `firestore:"blocked_reason" json:"blocked_reason,omitempty"`
+	// LastActiveAt is the last time the user logged in, refreshed a token
+	// or used an API key, to the minute.
+	LastActiveAt int64 `firestore:"last_active_at" json:"last_active_at,omitempty"`
+	// Admin users may request the admin scopes.
+	Admin bool `firestore:"admin" json:"admin"`
+	// Email is the verified address the user last logged in with.
+	Email string `firestore:"email" json:"email,omitempty"`
+	// KnownDevices holds the ids of the devices the user last logged in
+	// from, newest last, and GrantedClients the OAuth clients they have
+	// authorized, so only logins from new devices and new grants are
+	// notified.
+	KnownDevices   []string `firestore:"known_devices" json:"known_devices,omitempty"`
+	GrantedClients []string `firestore:"granted_clients" json:"granted_clients,omitempty"`
+
+	// Every login starts a new refresh token family and every refresh
+	// replaces RefreshTokenID with the id of the token handed out, so only
+	// the latest token of the latest family is accepted.
+	RefreshFamily    string `firestore:"refresh_family" json:"refresh_family,omitempty"`
+	RefreshTokenID   string `firestore:"refresh_token_id" json:"refresh_token_id,omitempty"`
+	RefreshExpiresAt int64  `firestore:"refresh_expires_at" json:"refresh_expires_at,omitempty"`
+}
+
+// RevokeRefreshTokens invalidates every refresh token issued to the user.
+func (u *User) RevokeRefreshTokens() {
+	u.RefreshFamily = ""
+	u.RefreshTokenID = ""
+	u.RefreshExpiresAt = 0
+}
+
+// maxKnownDevices bounds how many devices a user's document remembers.
+const maxKnownDevices = 20
+
+// RememberDevice records a login from the device and reports whether the user
+// had logged in before but never from it. The oldest device is forgotten once
+// maxKnownDevices are known.
+func (u *User) RememberDevice(device string) bool {
+	for i, known := range u.KnownDevices {
+		if known == device {
+			// Move it last so the devices in use are kept longest.
+			u.KnownDevices = append(append(u.KnownDevices[:i:i], u.KnownDevices[i+1:]...), device)
+			return false
+		}
+	}
+	isNew := len(u.KnownDevices) > 0
+	u.KnownDevices = append(u.KnownDevices, device)
+	if len(u.KnownDevices) > maxKnownDevices {
+		u.KnownDevices = u.KnownDevices[len(u.KnownDevices)-maxKnownDevices:]
+	}
+	return isNew
+}
+
+// RememberGrant records that the user authorized the OAuth client and reports
+// whether they hadn't before.
+func (u *User) RememberGrant(clientID string) bool {
+	for _, granted := range u.GrantedClients {
+		if granted == clientID {
+			return false
+		}
+	}
+	u.GrantedClients = append(u.GrantedClients, clientID)
+	return true
+}
+
+// APIKey is a document in the api_keys collection. Only a hash of the
+// secret part of the key is stored.
+type APIKey struct {
+	ID         string   `firestore:"id" json:"id"`
+	UID        string   `firestore:"uid" json:"uid"`
+	Name       string   `firestore:"name" json:"name"`
+	Hash       string   `firestore:"hash" json:"hash"`
+	Scopes     []string `firestore:"scopes" json:"scopes"`
+	CreatedAt  int64    `firestore:"created_at" json:"created_at"`
+	LastUsedAt int64    `firestore:"last_used_at" json:"last_used_at"`
+	RevokedAt  int64    `firestore:"revoked_at" json:"revoked_at"`
+}
+
+// AuditEntry is a document in the audit log: Actor did Action to Target.
+type AuditEntry struct {
+	ID     string `firestore:"-" json:"id"`
+	Actor  string `firestore:"actor" json:"actor"`
+	Action string `firestore:"action" json:"action"`
+	Target string `firestore:"target" json:"target"`
+	Reason string `firestore:"reason" json:"reason,omitempty"`
+	At     int64  `firestore:"at" json:"at"`
+}
+
+// Revoked reports whether the key has been revoked.
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != 0
+}
+
+// Client is a document in the oauth_clients collection: a third-party app
+// registered by OwnerUID. Public clients, such as single-page and mobile apps,
+// have no secret and rely on PKCE alone.
+type Client struct {
+	ID           string   `firestore:"id" json:"id"`
+	OwnerUID     string   `firestore:"owner_uid" json:"owner_uid"`
+	Name         string   `firestore:"name" json:"name"`
+	SecretHash   string   `firestore:"secret_hash" json:"secret_hash,omitempty"`
+	RedirectURIs []string `firestore:"redirect_uris" json:"redirect_uris"`
+	// Scopes bounds what the client may ask users for.
+	Scopes    []string `firestore:"scopes" json:"scopes"`
+	CreatedAt int64    `firestore:"created_at" json:"created_at"`
+	RevokedAt int64    `firestore:"revoked_at" json:"revoked_at"`
+}
+
+// Revoked reports whether the client has been revoked.
+func (c *Client) Revoked() bool {
+	return c.RevokedAt != 0
+}
+
+// Public reports whether the client has no secret.
+func (c *Client) Public() bool {
+	return c.SecretHash == ""
+}
+
+// AuthCode is a document in the oauth_codes collection: a user's consent to
+// a client, waiting to be exchanged for tokens. ID is a hash of the code.
+type AuthCode struct {
+	ID            string   `firestore:"id" json:"id"`
+	ClientID      string   `firestore:"client_id" json:"client_id"`
+	UID           string   `firestore:"uid" json:"uid"`
+	RedirectURI   string   `firestore:"redirect_uri" json:"redirect_uri"`
+	Scopes        []string `firestore:"scopes" json:"scopes"`
+	CodeChallenge string   `firestore:"code_challenge" json:"code_challenge"`
+	Nonce         string   `firestore:"nonce" json:"nonce,omitempty"`
+	ExpiresAt     int64    `firestore:"expires_at" json:"expires_at"`
+	UsedAt        int64    `firestore:"used_at" json:"used_at"`
+}
+
+// Store reads and writes users and their API keys.
+type Store interface {
+	// Get returns the user with the given uid.
+	Get(ctx context.Context, uid string) (*User, error)
+	// List returns every user, oldest first.
+	List(ctx context.Context) ([]User, error)
+	// Create adds a new user, failing with ErrExists if the uid is taken.
+	Create(ctx context.Context, user *User) error
+	// Update atomically applies fn to the stored user and saves the result
+	// unless fn returns an error, which Update then returns.
+	Update(ctx context.Context, uid string, fn func(*User) error) error
+
+	// GetAPIKey returns the API key with the given id.
+	GetAPIKey(ctx context.Context, id string) (*APIKey, error)
+	// ListAPIKeys returns every API key owned by uid, oldest first.
+	ListAPIKeys(ctx context.Context, uid string) ([]APIKey, error)
+	// CreateAPIKey adds a new API key.
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	// UpdateAPIKey atomically applies fn to the stored key, as Update does.
+	UpdateAPIKey(ctx context.Context, id string, fn func(*APIKey) error) error
+	// ReplaceAPIKey atomically applies fn to the stored key and adds the key
+	// fn returns, saving both unless fn returns an error, which ReplaceAPIKey
+	// then returns. fn may run more than once.
+	ReplaceAPIKey(ctx context.Context, id string, fn func(*APIKey) (*APIKey, error)) error
+	// TouchAPIKey records that the key was used at t.
+	TouchAPIKey(ctx context.Context, id string, t int64) error
+
+	// GetClient returns the OAuth client with the given id.
+	GetClient(ctx context.Context, id string) (*Client, error)
+	// ListClients returns every OAuth client owned by uid, oldest first.
+	ListClients(ctx context.Context, uid string) ([]Client, error)
+	// CreateClient adds a new OAuth client.
+	CreateClient(ctx context.Context, client *Client) error
+	// UpdateClient atomically applies fn to the stored client, as Update does.
+	UpdateClient(ctx context.Context, id string, fn func(*Client) error) error
+
+	// CreateAuthCode adds a new authorization code.
+	CreateAuthCode(ctx context.Context, code *AuthCode) error
+	// UpdateAuthCode atomically applies fn to the stored code, as Update does.
+	UpdateAuthCode(ctx context.Context, id string, fn func(*AuthCode) error) error
+
+	// UpdateAudited atomically applies fn to the stored user, as Update
+	// does, and appends entry to the audit log, setting its ID. Neither is
+	// saved without the other.
+	UpdateAudited(ctx context.Context, uid string, fn func(*User) error, entry *AuditEntry) error
+	// ListAuditLog returns up to limit entries, newest first, only those
+	// targeting target unless it is empty.
+	ListAuditLog(ctx context.Context, target string, limit int) ([]AuditEntry, error)
+
+	// Close releases any connection held by the store.
+	Close() error
+}
+
+// newestFirst sorts entries newest first and keeps at most limit of them.
+func newestFirst(entries []AuditEntry, limit int) []AuditEntry {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At > entries[j].At })
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
@@ -0,0 +1,28 @@
+package users
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRememberDevice(t *testing.T) {
+	var user User
+	if user.RememberDevice("d1") {
+		t.Error("first login reported as a new device")
+	}
+	if !user.RememberDevice("d2") {
+		t.Error("second device not reported as new")
+	}
+	if user.RememberDevice("d1") {
+		t.Error("known device reported as new")
+	}
+	for i := 0; i < maxKnownDevices; i++ {
+		user.RememberDevice(strconv.Itoa(i))
+	}
+	if len(user.KnownDevices) != maxKnownDevices {
+		t.Errorf("remembered %d devices, want %d", len(user.KnownDevices), maxKnownDevices)
+	}
+	if !user.RememberDevice("d1") {
+		t.Error("forgotten device not reported as new")
+	}
+}
@@ -0,0 +1,137 @@
+// Package portal backs the developer portal, where users see and manage
+// every credential issued for their account.
+package portal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	apikeys "github.com/asuc-octo/octoapi/auth/api-keys"
+	"github.com/asuc-octo/octoapi/auth/oauth"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/users"
+)
+
+// Credentials lists what can be used to call the API on the caller's behalf.
+// Secrets are never shown again after they are issued.
+type Credentials struct {
+	Session      Session          `json:"session"`
+	APIKeys      []apikeys.APIKey `json:"api_keys"`
+	OAuthClients []oauth.Client   `json:"oauth_clients"`
+}
+
+// Session describes the refresh token from the caller's latest login.
+type Session struct {
+	Active       bool   `json:"active"`
+	ExpiresAt    *int64 `json:"expires_at"`
+	LastActiveAt *int64 `json:"last_active_at"`
+}
+
+// CredentialsEndpoint lists the caller's login session, API keys and OAuth
+// clients, including revoked ones.
+func CredentialsEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/portal/credentials", credentialsEndpoint, http.MethodGet)
+}
+
+func credentialsEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateUser(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	user, err := db.Get(ctx, claims.UID)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users GET failed: %v", err)
+		return
+	}
+	keys, err := db.ListAPIKeys(ctx, claims.UID)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("API keys GET failed: %v", err)
+		return
+	}
+	clients, err := db.ListClients(ctx, claims.UID)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("OAuth clients GET failed: %v", err)
+		return
+	}
+
+	result := Credentials{
+		Session:      session(user),
+		APIKeys:      make([]apikeys.APIKey, 0, len(keys)),
+		OAuthClients: make([]oauth.Client, 0, len(clients)),
+	}
+	for _, key := range keys {
+		result.APIKeys = append(result.APIKeys, apikeys.View(key, ""))
+	}
+	for _, client := range clients {
+		result.OAuthClients = append(result.OAuthClients, oauth.View(client, ""))
+	}
+	output, err := json.Marshal(result)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("credentials JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
+
+// RevokeSessionEndpoint revokes the refresh token from the caller's latest
+// login, signing out every device using it. Access tokens already issued stay
+// valid until they expire.
+func RevokeSessionEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/portal/session/revoke", revokeSessionEndpoint, http.MethodPost)
+}
+
+func revokeSessionEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateUser(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	db, err := platform.OpenUsers(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("users store init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	err = db.Update(ctx, claims.UID, func(user *users.User) error {
+		user.RevokeRefreshTokens()
+		return nil
+	})
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("session revocation failed: %v", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func session(user *users.User) Session {
+	var s Session
+	if user.RefreshFamily != "" && time.Now().Unix() < user.RefreshExpiresAt {
+		s.Active = true
+		s.ExpiresAt = &user.RefreshExpiresAt
+	}
+	if user.LastActiveAt != 0 {
+		s.LastActiveAt = &user.LastActiveAt
+	}
+	return s
+}
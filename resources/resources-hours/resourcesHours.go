@@ -0,0 +1,83 @@
+package resourceshours
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
+)
+
+// ResourceHoursEndpoint returns the opening hours of one resource for each day
+// of a date range, this week by default, so apps can render an hours table.
+func ResourceHoursEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/resources/{name}/hours", resourceHoursEndpoint, http.MethodGet)
+}
+
+func resourceHoursEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !platform.Authenticate(w, r, platform.ScopeResourcesRead) {
+		return
+	}
+
+	name, ok := platform.NameParam(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := platform.DateRangeParams(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	db, err := platform.OpenStore(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
+		return
+	}
+	defer db.Close()
+	table, err := getResourceHours(ctx, db, name, from, to)
+	if err == store.ErrNotFound {
+		platform.WriteError(w, http.StatusNotFound, platform.Error{
+			Code:    platform.CodeNotFound,
+			Param:   "name",
+			Message: "No resource named '" + name + "'",
+		})
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("resource hours GET failed: %v", err)
+		return
+	}
+	output, err := json.Marshal(table)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("resource hours JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}
+
+func getResourceHours(ctx context.Context, db store.Store, name string, from, to time.Time) (*models.Timetable, error) {
+	doc, err := db.GetByName(ctx, store.Resources, name)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := models.DecodeResource(doc)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	table := models.NewTimetable(resource.Name, resource.Schedule(holidays), from, to)
+	return &table, nil
+}
@@ -3,190 +3,96 @@ package resourceslocation
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"reflect"
-	"strconv"
-
-	"cloud.google.com/go/firestore"
-	"github.com/gorilla/schema"
-	"github.com/martinlindhe/unit"
-	"github.com/umahmood/haversine"
-	"google.golang.org/api/iterator"
+	"time"
+
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-var client *firestore.Client
-var ctx context.Context
-var decoder = schema.NewDecoder()
-var floatType = reflect.TypeOf(float64(0))
-
-var unitMap = map[string]unit.Length{
-	"ft": unit.Foot,
-	"yd": unit.Yard,
-	"mi": unit.Mile,
-	"m":  unit.Meter,
-	"km": unit.Kilometer,
+// Resource is a campus resource with its distance from the requested point
+// in the requested unit.
+type Resource struct {
+	models.Resource
+	Distance float64 `json:"distance"`
 }
 
 func ResourcesLocationEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
+	platform.Serve(w, r, "/v1/resources/location", resourcesLocationEndpoint, http.MethodGet)
+}
+
+func resourcesLocationEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	if !platform.Authenticate(w, r, platform.ScopeResourcesRead) {
 		return
 	}
 
-	var radius float64
-	var longitude float64
-	var latitude float64
-	var units string
-	var err error
-	radiusInput, ok := r.URL.Query()["radius"]
-	if ok {
-		if len(radiusInput[0]) >= 1 {
-			radius, err = strconv.ParseFloat(radiusInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'radius' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(radiusInput[0]) < 1 {
-			http.Error(w, "Url Param 'radius' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'radius' is missing", http.StatusBadRequest)
-		return
-	}
-	longitudeInput, ok := r.URL.Query()["longitude"]
-	if ok {
-		if len(longitudeInput[0]) >= 1 {
-			longitude, err = strconv.ParseFloat(longitudeInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'longitude' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(longitudeInput[0]) < 1 {
-			http.Error(w, "Url Param 'longitude' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'longitude' is missing", http.StatusBadRequest)
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
-	latitudeInput, ok := r.URL.Query()["latitude"]
-	if ok {
-		if len(latitudeInput[0]) >= 1 {
-			latitude, err = strconv.ParseFloat(latitudeInput[0], 64)
-			if err != nil {
-				http.Error(w, "Url Param 'latitude' is of incorrect type", http.StatusBadRequest)
-				return
-			}
-		} else if len(latitudeInput[0]) < 1 {
-			http.Error(w, "Url Param 'latitude' is of incorrect type", http.StatusBadRequest)
-			return
-		}
-	} else {
-		http.Error(w, "Url Param 'latitude' is missing", http.StatusBadRequest)
+	area, ok := platform.AreaParams(w, r)
+	if !ok {
 		return
 	}
-
-	unitsInput, ok := r.URL.Query()["unit"]
-	if !ok || len(unitsInput) < 1 {
-		http.Error(w, "Url Param 'unit' is missing", http.StatusBadRequest)
+	page, ok := platform.PageParams(w, r)
+	if !ok {
 		return
 	}
-	units = unitsInput[0]
 
-	var kilometers float64
-	kilometers, err = convertToKilometers(radius, units)
+	db, err := platform.OpenStore(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
 		return
 	}
+	defer db.Close()
 
-	err = initFirestore(w)
+	resources, err := getResourceByRange(r.Context(), db, at, area)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
+		log.Printf("resources by range fetch failed: %v", err)
 		return
 	}
-
-	resources, err := getResourceByRange(longitude, latitude, kilometers)
-	jsonString, err := json.Marshal(resources)
+	start, end := page.Bounds(len(resources))
+	jsonString, err := json.Marshal(resources[start:end])
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		platform.InternalError(w)
 		return
 	}
 
 	fmt.Fprint(w, string(jsonString))
 }
 
-func getResourceByRange(longitude float64, latitude float64, radius float64) ([]map[string]interface{}, error) {
-
-	defer client.Close()
-	var resources []map[string]interface{}
+// getResourceByRange returns the resources in area, nearest first.
+func getResourceByRange(ctx context.Context, db store.Store, at time.Time, area platform.Area) ([]Resource, error) {
 
-	iter := client.Collection("Campus Resource").Documents(ctx)
+	resources := make([]Resource, 0)
 
-	for {
-		doc, err := iter.Next()
+	docs, err := area.Find(ctx, db, store.Resources)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
 
-		if err == iterator.Done {
-			break
-		}
+	for _, docData := range docs {
+		resource, err := models.DecodeResource(docData)
 		if err != nil {
-			return nil, err
-		}
-
-		docData := doc.Data()
-		docLat, ok := docData["latitude"].(float64)
-		docLon, ok := docData["longitude"].(float64)
-		if !ok {
-			log.Println("Error: Can't entry doesn't have location data available or is not of type float")
+			log.Printf("Skipping invalid resource: %v", err)
 			continue
 		}
 
-		_, km := haversine.Distance(haversine.Coord{Lat: latitude, Lon: longitude},
-			haversine.Coord{Lat: docLat, Lon: docLon})
-
-		delete(docData, "latitude")
-		if km <= radius {
-			resources = append(resources, docData)
-		}
+		resource.Evaluate(at, holidays)
+		distance := area.Distance(resource.Latitude, resource.Longitude)
+		resources = append(resources, Resource{Resource: resource, Distance: distance})
 	}
 	return resources, nil
 }
-
-func convertToKilometers(value float64, units string) (float64, error) {
-	unitConvert, valid := unitMap[units]
-	if valid {
-		return (unit.Length(value) * unitConvert).Kilometers(), nil
-	} else {
-		return 0, errors.New("URL Param 'unit' is incorrect")
-	}
-}
-
-func getFloat(unk interface{}) (float64, error) {
-	v := reflect.ValueOf(unk)
-	v = reflect.Indirect(v)
-	if !v.Type().ConvertibleTo(floatType) {
-		return 0, fmt.Errorf("cannot convert %v to float64", v.Type())
-	}
-	fv := v.Convert(floatType)
-	return fv.Float(), nil
-}
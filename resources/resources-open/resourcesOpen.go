@@ -8,90 +8,70 @@ import (
 	"net/http"
 	"time"
 
-	"cloud.google.com/go/firestore"
-	"github.com/gorilla/schema"
-	"google.golang.org/api/iterator"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-var client *firestore.Client
-var ctx context.Context
-var decoder = schema.NewDecoder()
-
 func ResourcesOpenEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
+	platform.Serve(w, r, "/v1/resources/open", resourcesOpenEndpoint, http.MethodGet)
+}
+
+func resourcesOpenEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	if !platform.Authenticate(w, r, platform.ScopeResourcesRead) {
+		return
+	}
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
-	err := initFirestore(w)
+	db, err := platform.OpenStore(r.Context())
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
 		return
 	}
+	defer db.Close()
 
-	resources, err := getOpenResources(w)
+	resources, err := getOpenResources(r.Context(), db, at)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("libraries search GET failed: %v", err)
 		return
 	}
 	jsonString, err := json.Marshal(resources)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("libraries json convert failed: %v", err)
 		return
 	}
 	fmt.Fprint(w, string(jsonString))
 }
 
-func getOpenResources(w http.ResponseWriter) ([]map[string]interface{}, error) {
-
-	defer client.Close()
-	var resources []map[string]interface{}
-
-	timestamp := time.Now().Unix()
-
-	iter := client.Collection("Campus Resource").Documents(ctx)
-
-	for {
-		doc, err := iter.Next()
+func getOpenResources(ctx context.Context, db store.Store, at time.Time) ([]models.Resource, error) {
+	resources := make([]models.Resource, 0)
+	docs, err := db.List(ctx, store.Resources)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
 
-		if err == iterator.Done {
-			break
-		}
+	for _, docData := range docs {
+		resource, err := models.DecodeResource(docData)
 		if err != nil {
-			http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
-			log.Printf("resources fetch data failed %v", err)
-			return nil, err
+			log.Printf("Skipping invalid resource: %v", err)
+			continue
 		}
-		docData := doc.Data()
-		timeArray := docData["open_close_array"].([]interface{})
-		for i := 0; i < len(timeArray); i++ {
-			entry := timeArray[i].(map[string]interface{})
-			openTime := int64(entry["open_time"].(float64))
-			closeTime := int64(entry["close_time"].(float64))
-
-			if timestamp < openTime || timestamp > closeTime {
-				continue
-			}
+		resource.Evaluate(at, holidays)
+		if resource.IsOpen {
+			resources = append(resources, resource)
 		}
-
-		resources = append(resources, docData)
 	}
 	return resources, nil
 }
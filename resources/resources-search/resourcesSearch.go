@@ -6,59 +6,63 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
-	"cloud.google.com/go/firestore"
-	"google.golang.org/api/iterator"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-var client *firestore.Client
-var ctx context.Context
-
+// ResourcesSearchEndpoint returns the resource with the given name, or 404
+// when there is none.
 func ResourcesSearchEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
+	platform.Serve(w, r, "/v1/resources/search", resourcesSearchEndpoint, http.MethodGet)
+}
+
+func resourcesSearchEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	if !platform.Authenticate(w, r, platform.ScopeResourcesRead) {
 		return
 	}
 
-	err := initFirestore(w)
+	db, err := platform.OpenStore(r.Context())
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
-		log.Printf("Firestore Init failed: %v", err)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
 		return
 	}
+	defer db.Close()
 
 	name, ok := r.URL.Query()["name"]
 
 	if !ok || len(name[0]) < 1 {
-		http.Error(w, "Url Param 'name' is missing", http.StatusBadRequest)
+		platform.MissingParam(w, "name")
+		return
+	}
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
-	resources, err := getResourceByName(name[0])
+	resources, err := getResourceByName(r.Context(), db, name[0], at)
+	if err == store.ErrNotFound {
+		platform.WriteError(w, http.StatusNotFound, platform.Error{
+			Code:    platform.CodeNotFound,
+			Param:   "name",
+			Message: "No resource named '" + name[0] + "'",
+		})
+		return
+	}
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("resources by name fetch failed: %v", err)
 		return
 	}
 
 	jsonString, err := json.Marshal(resources)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		log.Printf("JSON conversion failed: %v", err)
 		return
 	}
@@ -66,25 +70,21 @@ func ResourcesSearchEndpoint(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, string(jsonString))
 }
 
-func getResourceByName(name string) (map[string]interface{}, error) {
+func getResourceByName(ctx context.Context, db store.Store, name string, at time.Time) (*models.Resource, error) {
 
-	defer client.Close()
-	var resource map[string]interface{}
-
-	iter := client.Collection("Campus Resource").Where("name", "==", name).Documents(ctx)
-
-	for {
-		doc, err := iter.Next()
-
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		resource = doc.Data()
-		break
+	doc, err := db.GetByName(ctx, store.Resources, name)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := models.DecodeResource(doc)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
 	}
+	resource.Evaluate(at, holidays)
 
-	return resource, nil
+	return &resource, nil
 }
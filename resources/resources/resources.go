@@ -6,73 +6,79 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"time"
 
-	"cloud.google.com/go/firestore"
-	"google.golang.org/api/iterator"
+	"github.com/asuc-octo/octoapi/platform"
+	"github.com/asuc-octo/octoapi/platform/models"
+	"github.com/asuc-octo/octoapi/platform/store"
 )
 
-var client *firestore.Client
-var ctx context.Context
-
 func CampusResourceEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
+	platform.Serve(w, r, "/v1/resources", campusResourceEndpoint, http.MethodGet)
+}
+
+func campusResourceEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !platform.Authenticate(w, r, platform.ScopeResourcesRead) {
 		return
 	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	at, ok := platform.TimeParam(w, r)
+	if !ok {
 		return
 	}
 
-	err := initFirestore(w)
+	db, err := platform.OpenStore(r.Context())
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
+		log.Printf("Store Init failed: %v", err)
 		return
 	}
+	defer db.Close()
 
-	jsonString, err := json.Marshal(getAllResources(w))
+	resources, err := getAllResources(r.Context(), db, at)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
+		log.Printf("resources fetch data failed: %v", err)
+		return
+	}
+
+	jsonString, err := json.Marshal(resources)
+	if err != nil {
+		platform.InternalError(w)
 		return
 	}
 
 	fmt.Fprint(w, string(jsonString))
 }
 
-func getAllResources(w http.ResponseWriter) []map[string]interface{} {
-
-	defer client.Close()
-	var resources []map[string]interface{}
+func getAllResources(ctx context.Context, db store.Store, at time.Time) ([]models.Resource, error) {
 
-	iter := client.Collection("Campus Resource").Documents(ctx)
+	var resources []models.Resource
 
-	for {
-		doc, err := iter.Next()
+	docs, err := db.List(ctx, store.Resources)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := models.LoadHolidays(ctx, db)
+	if err != nil {
+		return nil, err
+	}
 
-		if err == iterator.Done {
-			break
-		}
+	for _, doc := range docs {
+		resource, err := models.DecodeResource(doc)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return nil
+			log.Printf("Skipping invalid resource: %v", err)
+			continue
 		}
-
-		resources = append(resources, doc.Data())
+		resource.Evaluate(at, holidays)
+		resources = append(resources, resource)
 	}
 
-	return resources
+	return resources, nil
 }
 
 func StreamToByte(stream io.Reader) []byte {
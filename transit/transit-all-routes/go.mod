@@ -1 +0,0 @@
-module example.com/cloudfunction
\ No newline at end of file
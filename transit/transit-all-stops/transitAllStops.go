@@ -11,6 +11,8 @@ import (
 
 	"github.com/gorilla/schema"
 	"github.com/martinlindhe/unit"
+
+	"github.com/asuc-octo/octoapi/platform"
 )
 
 var apiURL = "http://api.actransit.org/transit/stops/{latitude}/{latitude}/{distance}"
@@ -20,23 +22,13 @@ var defaultDistance = 3.0
 var decoder = schema.NewDecoder()
 
 func TransitAllStopsEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
+	platform.Serve(w, r, "/v1/transit/stops", transitAllStopsEndpoint, http.MethodGet)
+}
+
+func transitAllStopsEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	if !platform.Authenticate(w, r, platform.ScopeTransitRead) {
 		return
 	}
 
@@ -51,7 +43,10 @@ func TransitAllStopsEndpoint(w http.ResponseWriter, r *http.Request) {
 
 	// Set default query parameters
 	if err != nil {
-		http.Error(w, "Error parsing parameters: "+err.Error(), http.StatusBadRequest)
+		platform.WriteError(w, http.StatusBadRequest, platform.Error{
+			Code:    platform.CodeInvalidParam,
+			Message: "Error parsing parameters: " + err.Error(),
+		})
 		return
 	}
 	if input.Longitude == "" || input.Latitude == "" {
@@ -65,19 +60,19 @@ func TransitAllStopsEndpoint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Read Transit API Key from Secrets Manager
-	key, err := getTransitSecret(w)
+	key, err := platform.Secret(r.Context(), platform.TransitKeySecret)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		return
 	}
 	stops, err := getAllStops(w, input.Longitude, input.Latitude, convertToFeet(input.Radius, input.Unit), key)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		return
 	}
 	jsonString, err := json.Marshal(stops)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		return
 	}
 
@@ -5,28 +5,20 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/asuc-octo/octoapi/platform"
 )
 
 var apiURL = "http://api.actransit.org/transit/stop/{stopId}/destinations"
 
 func TransitRouteByStopEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "*")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
-		return
-	}
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH,OPTIONS")
+	platform.Serve(w, r, "/v1/transit/routes/by-stop", transitRouteByStopEndpoint, http.MethodGet)
+}
+
+func transitRouteByStopEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
 
-	tokenValid := validateAccessToken(r)
-	if !tokenValid {
-		http.Error(w, "Invalid Access Token: Make sure you are passing in an access token in the header of your request using bearer token authentication. To get your token please visit the Getting Started section on our API documentation page. Access tokens expire within 2 days, so make sure you retrieve your new valid access token using the refresh_token endpoint.", http.StatusBadRequest)
+	if !platform.Authenticate(w, r, platform.ScopeTransitRead) {
 		return
 	}
 
@@ -34,27 +26,27 @@ func TransitRouteByStopEndpoint(w http.ResponseWriter, r *http.Request) {
 	stopID, ok := r.URL.Query()["stopID"]
 
 	if !ok || len(stopID[0]) < 1 {
-		http.Error(w, "Url Param ‘stopID’ is of incorrect type", http.StatusBadRequest)
+		platform.MissingParam(w, "stopID")
 		return
 	}
 	// Read Transit API Key from Secrets Manager
-	key, err := getTransitSecret(w)
+	key, err := platform.Secret(r.Context(), platform.TransitKeySecret)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		return
 	}
 
 	// Call Transit API to obtain all routes
 	routes, err := getRoutesByStop(w, key, stopID[0])
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		return
 	}
 
 	// Format results to JSON
 	jsonString, err := json.Marshal(routes)
 	if err != nil {
-		http.Error(w, "Something went wrong. Please try again later.", http.StatusInternalServerError)
+		platform.InternalError(w)
 		return
 	}
 
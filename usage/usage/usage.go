@@ -0,0 +1,82 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/asuc-octo/octoapi/platform"
+	usagestats "github.com/asuc-octo/octoapi/platform/usage"
+)
+
+// Usage is the caller's usage over a time range, per endpoint.
+type Usage struct {
+	From      int64              `json:"from"`
+	To        int64              `json:"to"`
+	Requests  int                `json:"requests"`
+	Errors    int                `json:"errors"`
+	Endpoints []usagestats.Count `json:"endpoints"`
+}
+
+// UsageEndpoint reports how often the caller called each endpoint between
+// the from and to query params, in Unix seconds, by default the last 30 days.
+// The key_id query param limits the report to one of the caller's API keys.
+func UsageEndpoint(w http.ResponseWriter, r *http.Request) {
+	platform.Serve(w, r, "/v1/usage", usageEndpoint, http.MethodGet)
+}
+
+func usageEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := platform.AuthenticateClaims(w, r)
+	if !ok {
+		return
+	}
+	q, ok := platform.UsageQuery(w, r)
+	if !ok {
+		return
+	}
+	q.UID = claims.UID
+	q.KeyID = r.URL.Query().Get("key_id")
+
+	ctx := r.Context()
+	sink, err := platform.UsageSink(ctx)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("usage sink init failed: %v", err)
+		return
+	}
+	var records []usagestats.Record
+	if sink != nil {
+		records, err = sink.Records(ctx, q)
+	}
+	if sink == nil || err == usagestats.ErrNotQueryable {
+		platform.WriteError(w, http.StatusNotImplemented, platform.Error{
+			Code:    platform.CodeInvalidRequest,
+			Message: "Usage reports aren't available on this deployment.",
+		})
+		return
+	}
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("usage GET failed: %v", err)
+		return
+	}
+	result := Usage{
+		From:      q.From,
+		To:        q.To,
+		Endpoints: usagestats.Summarize(records, true, false),
+	}
+	for _, count := range result.Endpoints {
+		result.Requests += count.Requests
+		result.Errors += count.Errors
+	}
+	output, err := json.Marshal(result)
+	if err != nil {
+		platform.InternalError(w)
+		log.Printf("usage JSON conversion failed: %v", err)
+		return
+	}
+	fmt.Fprint(w, string(output))
+}